@@ -0,0 +1,147 @@
+package ospfip
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	ATTRIBUTE_TTL      = "ttl"
+	ATTRIBUTE_WEIGHT   = "weight"
+	ATTRIBUTE_PRIORITY = "priority"
+)
+
+// attributes of a record set through tags like 'coredns:plugin:ospfip:ttl=60'
+type attributes struct {
+	ttl      uint32
+	weight   int
+	priority *int
+}
+
+// return if a tag holds an attribute rather than a hostname
+func isAttributeTag(tag string) bool {
+	return strings.HasPrefix(tag, PLUGIN_TAG_IDENTIFIER+":") && strings.Contains(tag, "=") && !isTypedTag(tag)
+}
+
+// extract the attributes from a list of tags, invalid attributes are returned as rejected
+func attributesFromTags(tags []string) (attributes, []rejection) {
+	var attrs attributes
+	rejected := make([]rejection, 0)
+	for _, tag := range tags {
+		if !isAttributeTag(tag) {
+			continue
+		}
+		key, value, _ := strings.Cut(strings.TrimPrefix(tag, PLUGIN_TAG_IDENTIFIER+":"), "=")
+		switch key {
+		case ATTRIBUTE_TTL:
+			ttl, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				rejected = append(rejected, rejection{Tag: tag, Reason: fmt.Sprintf("invalid ttl %q", value)})
+				continue
+			}
+			attrs.ttl = uint32(ttl)
+		case ATTRIBUTE_WEIGHT:
+			weight, err := strconv.Atoi(value)
+			if err != nil || weight < 1 {
+				rejected = append(rejected, rejection{Tag: tag, Reason: fmt.Sprintf("invalid weight %q", value)})
+				continue
+			}
+			attrs.weight = weight
+		case ATTRIBUTE_PRIORITY:
+			priority, err := strconv.Atoi(value)
+			if err != nil || priority < 0 {
+				rejected = append(rejected, rejection{Tag: tag, Reason: fmt.Sprintf("invalid priority %q", value)})
+				continue
+			}
+			attrs.priority = &priority
+		default:
+			rejected = append(rejected, rejection{Tag: tag, Reason: fmt.Sprintf("unknown attribute %q", key)})
+		}
+	}
+	return attrs, rejected
+}
+
+// return the weight per name, type and address of the records which have a weight set
+func weightsFromRecords(records []record) map[recordKey]int {
+	weights := make(map[recordKey]int)
+	for _, r := range records {
+		if r.Weight > 0 {
+			weights[keyOf(r)] = r.Weight
+		}
+	}
+	return weights
+}
+
+// return the key of the record an A or AAAA answer was built from
+func keyOfRR(rr dns.RR) recordKey {
+	return recordKey{name: rr.Header().Name, rrType: dns.TypeToString[rr.Header().Rrtype], ip: addressOf(rr).String()}
+}
+
+// weightedShuffle orders the A and AAAA records of an answer by weighted random selection,
+// so the first address is picked with a probability proportional to its weight.
+// Addresses without a weight count as weight 1, the answer is left as is when none has a weight.
+func weightedShuffle(answer []dns.RR, weights map[recordKey]int) []dns.RR {
+	if len(weights) == 0 {
+		return answer
+	}
+	addresses := make([]dns.RR, 0, len(answer))
+	others := make([]dns.RR, 0)
+	weighted := false
+	for _, rr := range answer {
+		ip := addressOf(rr)
+		if ip == nil {
+			others = append(others, rr)
+			continue
+		}
+		if _, ok := weights[keyOfRR(rr)]; ok {
+			weighted = true
+		}
+		addresses = append(addresses, rr)
+	}
+	if !weighted || len(addresses) < 2 {
+		return answer
+	}
+
+	weightOf := func(rr dns.RR) int {
+		if w, ok := weights[keyOfRR(rr)]; ok {
+			return w
+		}
+		return 1
+	}
+	shuffled := make([]dns.RR, 0, len(answer))
+	shuffled = append(shuffled, others...)
+	for len(addresses) > 0 {
+		total := 0
+		for _, rr := range addresses {
+			total += weightOf(rr)
+		}
+		pick := 0
+		n := rand.Intn(total)
+		for i, rr := range addresses {
+			n -= weightOf(rr)
+			if n < 0 {
+				pick = i
+				break
+			}
+		}
+		shuffled = append(shuffled, addresses[pick])
+		addresses = append(addresses[:pick], addresses[pick+1:]...)
+	}
+	return shuffled
+}
+
+// return the address of an A or AAAA record, nil for other types
+func addressOf(rr dns.RR) net.IP {
+	switch v := rr.(type) {
+	case *dns.A:
+		return v.A
+	case *dns.AAAA:
+		return v.AAAA
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package ospfip
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+)
+
+func TestAttributesFromTags(t *testing.T) {
+	cases := []struct {
+		name             string
+		tags             []string
+		expected         attributes
+		expectedRejected int
+	}{
+		{name: "no attributes", tags: []string{"coredns:plugin:ospfip", "coredns:plugin:ospfip:api.example.net"}, expected: attributes{}},
+		{name: "ttl and weight", tags: []string{"coredns:plugin:ospfip:ttl=60", "coredns:plugin:ospfip:weight=3"}, expected: attributes{ttl: 60, weight: 3}},
+		{name: "invalid ttl", tags: []string{"coredns:plugin:ospfip:ttl=-1"}, expected: attributes{}, expectedRejected: 1},
+		{name: "invalid weight", tags: []string{"coredns:plugin:ospfip:weight=0"}, expected: attributes{}, expectedRejected: 1},
+		{name: "unknown attribute", tags: []string{"coredns:plugin:ospfip:color=blue"}, expected: attributes{}, expectedRejected: 1},
+		{name: "foreign tag", tags: []string{"owner=team-a"}, expected: attributes{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rejected := attributesFromTags(tt.tags)
+			if got != tt.expected {
+				t.Fatalf("expected %+v, got %+v", tt.expected, got)
+			}
+			if len(rejected) != tt.expectedRejected {
+				t.Fatalf("expected %d rejected, got %+v", tt.expectedRejected, rejected)
+			}
+		})
+	}
+}
+
+func TestRecordFromTagsSkipsAttributes(t *testing.T) {
+	got, rejected := recordFromTags([]string{"coredns:plugin:ospfip:ttl=60", "coredns:plugin:ospfip:api.example.net"})
+	if got != "api.example.net" {
+		t.Fatalf("expected api.example.net, got %s", got)
+	}
+	if len(rejected) != 0 {
+		t.Fatalf("expected attribute tags not to be rejected as domain, got %+v", rejected)
+	}
+}
+
+func TestBuildZonesRecordTTL(t *testing.T) {
+	zones, _, reverseRecords, reverseTTLs, _ := buildZones([]record{
+		{Name: "a.example.org.", Zone: "example.org.", Type: "A", IP: "192.0.2.1", TTL: 60},
+		{Name: "b.example.org.", Zone: "example.org.", Type: "A", IP: "192.0.2.2"},
+	}, 3600)
+	elem, ok := zones["example.org."].Search("a.example.org.")
+	if !ok {
+		t.Fatalf("expected a.example.org. in zone")
+	}
+	if ttl := elem.Type(dns.TypeA)[0].Header().Ttl; ttl != 60 {
+		t.Fatalf("expected ttl 60, got %d", ttl)
+	}
+
+	// the ttl attribute applies to the PTR record as well
+	reverseZones, err := buildConfiguredReverseZones([]string{"2.0.192.in-addr.arpa."}, nil, reverseRecords, reverseTTLs, 3600)
+	if err != nil {
+		t.Fatalf("failed to build reverse zones: %s", err)
+	}
+	for addr, expected := range map[string]uint32{"1.2.0.192.in-addr.arpa.": 60, "2.2.0.192.in-addr.arpa.": 3600} {
+		elem, ok := reverseZones["2.0.192.in-addr.arpa."].Search(addr)
+		if !ok {
+			t.Fatalf("expected %s in reverse zone", addr)
+		}
+		if ttl := elem.Type(dns.TypePTR)[0].Header().Ttl; ttl != expected {
+			t.Fatalf("expected ttl %d for %s, got %d", expected, addr, ttl)
+		}
+	}
+}
+
+func TestWeightedShuffle(t *testing.T) {
+	answer := []dns.RR{
+		test.A("app.example.org. 60 IN A 192.0.2.1"),
+		test.A("app.example.org. 60 IN A 192.0.2.2"),
+	}
+	if got := weightedShuffle(answer, nil); got[0] != answer[0] {
+		t.Fatalf("expected answer to be left as is without weights")
+	}
+
+	weights := weightsFromRecords([]record{
+		{Name: "app.example.org.", Type: "A", IP: "192.0.2.1", Weight: 1000},
+		{Name: "app.example.org.", Type: "A", IP: "192.0.2.2"},
+	})
+	heavy := 0
+	for i := 0; i < 200; i++ {
+		got := weightedShuffle(answer, weights)
+		if len(got) != 2 {
+			t.Fatalf("expected all addresses to be kept, got %v", got)
+		}
+		if got[0].(*dns.A).A.String() == "192.0.2.1" {
+			heavy++
+		}
+	}
+	if heavy < 190 {
+		t.Fatalf("expected the heavy address to be picked first most of the time, got %d/200", heavy)
+	}
+
+	// the weight of an address doesn't apply to other names sharing it
+	other := []dns.RR{
+		test.A("other.example.org. 60 IN A 192.0.2.1"),
+		test.A("other.example.org. 60 IN A 192.0.2.2"),
+	}
+	heavy = 0
+	for i := 0; i < 200; i++ {
+		if weightedShuffle(other, weights)[0].(*dns.A).A.String() == "192.0.2.1" {
+			heavy++
+		}
+	}
+	if heavy != 200 {
+		t.Fatalf("expected the answer of a name without weights to be left as is, got %d/200", heavy)
+	}
+}
@@ -0,0 +1,272 @@
+package ospfip
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	CHANGE_ADDED   = "added"
+	CHANGE_REMOVED = "removed"
+	CHANGE_CHANGED = "changed"
+
+	WEBHOOK_ATTEMPTS = 3
+	WEBHOOK_BACKOFF  = time.Second
+	WEBHOOK_TIMEOUT  = 10 * time.Second
+	WEBHOOK_QUEUE    = 64
+	// how long shutdown waits for queued events to be delivered before dropping them
+	WEBHOOK_CLOSE_TIMEOUT = 5 * time.Second
+)
+
+// changeEvent describes a difference in the published records between two syncs
+type changeEvent struct {
+	Action    string    `json:"action"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	OldIP     string    `json:"old_ip,omitempty"`
+	NewIP     string    `json:"new_ip,omitempty"`
+	FipID     string    `json:"fip_id"`
+	ProjectID string    `json:"project_id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// auditSink receives the change events of every sync which changed the published records
+type auditSink interface {
+	Send(events []changeEvent) error
+	Close() error
+}
+
+// diffRecords returns the change events between the previous and current records.
+// Records are compared per name and type, a name which resolved to a single IP before and after
+// is reported as changed, otherwise the individual IP's are reported as added or removed.
+func diffRecords(previous, current []record, at time.Time) []changeEvent {
+	type key struct{ name, rrType string }
+	group := func(records []record) map[key]map[string]record {
+		grouped := make(map[key]map[string]record)
+		for _, r := range records {
+			k := key{r.Name, r.Type}
+			if grouped[k] == nil {
+				grouped[k] = make(map[string]record)
+			}
+			grouped[k][r.IP] = r
+		}
+		return grouped
+	}
+	before, after := group(previous), group(current)
+
+	events := make([]changeEvent, 0)
+	for k, old := range before {
+		cur := after[k]
+		if len(old) == 1 && len(cur) == 1 {
+			for oldIP, o := range old {
+				for newIP, n := range cur {
+					if oldIP != newIP || o.FipID != n.FipID {
+						events = append(events, changeEvent{Action: CHANGE_CHANGED, Name: k.name, Type: k.rrType, OldIP: oldIP, NewIP: newIP, FipID: n.FipID, ProjectID: n.ProjectID, Timestamp: at})
+					}
+				}
+			}
+			continue
+		}
+		for ip, o := range old {
+			if _, ok := cur[ip]; !ok {
+				events = append(events, changeEvent{Action: CHANGE_REMOVED, Name: k.name, Type: k.rrType, OldIP: ip, FipID: o.FipID, ProjectID: o.ProjectID, Timestamp: at})
+			}
+		}
+		for ip, n := range cur {
+			if _, ok := old[ip]; !ok {
+				events = append(events, changeEvent{Action: CHANGE_ADDED, Name: k.name, Type: k.rrType, NewIP: ip, FipID: n.FipID, ProjectID: n.ProjectID, Timestamp: at})
+			}
+		}
+	}
+	for k, cur := range after {
+		if _, ok := before[k]; ok {
+			continue
+		}
+		for ip, n := range cur {
+			events = append(events, changeEvent{Action: CHANGE_ADDED, Name: k.name, Type: k.rrType, NewIP: ip, FipID: n.FipID, ProjectID: n.ProjectID, Timestamp: at})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Name != events[j].Name {
+			return events[i].Name < events[j].Name
+		}
+		if events[i].Action != events[j].Action {
+			return events[i].Action < events[j].Action
+		}
+		return events[i].OldIP+events[i].NewIP < events[j].OldIP+events[j].NewIP
+	})
+	return events
+}
+
+// fileSink appends change events as JSON lines to a file
+type fileSink struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func newFileSink(path string) *fileSink {
+	return &fileSink{path: path}
+}
+
+func (s *fileSink) Send(events []changeEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %v", err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to write audit event: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *fileSink) Close() error { return nil }
+
+// webhookSink POSTs the change events of a sync as a JSON array to a URL.
+// Events are delivered in the background so a slow receiver doesn't hold back the sync,
+// the delivery starts with the first events so an unused sink doesn't leave anything running.
+type webhookSink struct {
+	url     string
+	client  *http.Client
+	backoff time.Duration
+	timeout time.Duration
+	queue   chan []changeEvent
+	done    chan struct{}
+	// aborts the delivery when closing takes too long
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mutex   sync.Mutex
+	started bool
+	closed  bool
+}
+
+func newWebhookSink(url string) *webhookSink {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookSink{
+		url:     url,
+		client:  &http.Client{Timeout: WEBHOOK_TIMEOUT},
+		backoff: WEBHOOK_BACKOFF,
+		timeout: WEBHOOK_CLOSE_TIMEOUT,
+		queue:   make(chan []changeEvent, WEBHOOK_QUEUE),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Send queues the events, a sync publishing during shutdown finds the sink closed
+func (s *webhookSink) Send(events []changeEvent) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		return fmt.Errorf("webhook sink for %s is closed, dropping %d event(s)", s.url, len(events))
+	}
+	if !s.started {
+		s.started = true
+		go s.run()
+	}
+	select {
+	case s.queue <- events:
+		return nil
+	default:
+		return fmt.Errorf("webhook queue for %s is full, dropping %d event(s)", s.url, len(events))
+	}
+}
+
+// Close waits a bounded time for the queued events to be delivered, the remaining ones are dropped
+// so a slow receiver doesn't hold back a reload or shutdown
+func (s *webhookSink) Close() error {
+	s.mutex.Lock()
+	started := s.started
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mutex.Unlock()
+	if !started {
+		s.cancel()
+		return nil
+	}
+	select {
+	case <-s.done:
+	case <-time.After(s.timeout):
+		// aborting the delivery makes the remaining events drain right away
+		s.cancel()
+		<-s.done
+	}
+	s.cancel()
+	return nil
+}
+
+func (s *webhookSink) run() {
+	defer close(s.done)
+	dropped := 0
+	for events := range s.queue {
+		if s.ctx.Err() != nil {
+			dropped += len(events)
+			continue
+		}
+		if err := s.post(events); err != nil {
+			if s.ctx.Err() != nil {
+				dropped += len(events)
+				continue
+			}
+			log.Errorf("failed to deliver %d change event(s) to %s: %v", len(events), s.url, err)
+		}
+	}
+	if dropped > 0 {
+		log.Errorf("dropped %d change event(s) for %s, they weren't delivered within %s of shutting down", dropped, s.url, s.timeout)
+	}
+}
+
+// post the events, retrying with an exponential backoff on connection errors and server errors
+func (s *webhookSink) post(events []changeEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	backoff := s.backoff
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("unexpected status %s", resp.Status)
+			if resp.StatusCode < 500 {
+				return err
+			}
+		}
+		if attempt >= WEBHOOK_ATTEMPTS {
+			return err
+		}
+		log.Debugf("attempt %d to deliver change events to %s failed: %v", attempt, s.url, err)
+		select {
+		case <-time.After(backoff):
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,179 @@
+package ospfip
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDiffRecords(t *testing.T) {
+	api := record{Name: "api.example.org.", Type: "A", IP: "192.0.2.1", FipID: "fip-1", ProjectID: "p1"}
+	apiMoved := record{Name: "api.example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-2", ProjectID: "p1"}
+	apps := record{Name: "*.apps.example.org.", Type: "A", IP: "192.0.2.3", FipID: "fip-3", ProjectID: "p2"}
+	apps2 := record{Name: "*.apps.example.org.", Type: "A", IP: "192.0.2.4", FipID: "fip-4", ProjectID: "p2"}
+
+	cases := []struct {
+		name     string
+		previous []record
+		current  []record
+		expected []changeEvent
+	}{
+		{
+			name:     "unchanged records",
+			previous: []record{api},
+			current:  []record{api},
+			expected: []changeEvent{},
+		},
+		{
+			name:     "added record",
+			previous: []record{},
+			current:  []record{api},
+			expected: []changeEvent{{Action: CHANGE_ADDED, Name: api.Name, Type: "A", NewIP: api.IP, FipID: api.FipID, ProjectID: api.ProjectID}},
+		},
+		{
+			name:     "removed record",
+			previous: []record{api},
+			current:  []record{},
+			expected: []changeEvent{{Action: CHANGE_REMOVED, Name: api.Name, Type: "A", OldIP: api.IP, FipID: api.FipID, ProjectID: api.ProjectID}},
+		},
+		{
+			name:     "record moved to another ip",
+			previous: []record{api},
+			current:  []record{apiMoved},
+			expected: []changeEvent{{Action: CHANGE_CHANGED, Name: api.Name, Type: "A", OldIP: api.IP, NewIP: apiMoved.IP, FipID: apiMoved.FipID, ProjectID: apiMoved.ProjectID}},
+		},
+		{
+			name:     "additional ip for an existing name",
+			previous: []record{apps},
+			current:  []record{apps, apps2},
+			expected: []changeEvent{{Action: CHANGE_ADDED, Name: apps.Name, Type: "A", NewIP: apps2.IP, FipID: apps2.FipID, ProjectID: apps2.ProjectID}},
+		},
+	}
+	at := time.Now()
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffRecords(tt.previous, tt.current, at)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %+v, got %+v", tt.expected, got)
+			}
+			for i := range got {
+				tt.expected[i].Timestamp = at
+				if got[i] != tt.expected[i] {
+					t.Fatalf("expected %+v, got %+v", tt.expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink := newFileSink(path)
+	events := []changeEvent{
+		{Action: CHANGE_ADDED, Name: "a.example.org.", Type: "A", NewIP: "192.0.2.1", FipID: "fip-1"},
+		{Action: CHANGE_REMOVED, Name: "b.example.org.", Type: "A", OldIP: "192.0.2.2", FipID: "fip-2"},
+	}
+	if err := sink.Send(events[:1]); err != nil {
+		t.Fatalf("failed to send events: %s", err)
+	}
+	if err := sink.Send(events[1:]); err != nil {
+		t.Fatalf("failed to send events: %s", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit file: %s", err)
+	}
+	defer f.Close()
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e changeEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("failed to decode line %d: %s", lines, err)
+		}
+		if e.Name != events[lines].Name {
+			t.Fatalf("expected %s, got %s", events[lines].Name, e.Name)
+		}
+		lines++
+	}
+	if lines != len(events) {
+		t.Fatalf("expected %d lines, got %d", len(events), lines)
+	}
+}
+
+func TestWebhookSinkRetries(t *testing.T) {
+	var calls int32
+	received := make(chan []changeEvent, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var events []changeEvent
+		json.NewDecoder(r.Body).Decode(&events)
+		received <- events
+	}))
+	defer srv.Close()
+
+	sink := newWebhookSink(srv.URL)
+	sink.backoff = time.Millisecond
+	if err := sink.Send([]changeEvent{{Action: CHANGE_ADDED, Name: "a.example.org."}}); err != nil {
+		t.Fatalf("failed to send events: %s", err)
+	}
+	sink.Close()
+
+	select {
+	case events := <-received:
+		if len(events) != 1 || events[0].Name != "a.example.org." {
+			t.Fatalf("expected event for a.example.org., got %+v", events)
+		}
+	default:
+		t.Fatalf("expected events to be delivered after a retry")
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestWebhookSinkClosed(t *testing.T) {
+	// an unused sink closes without anything to wait for
+	sink := newWebhookSink("http://127.0.0.1:0/")
+	sink.Close()
+	if err := sink.Send([]changeEvent{{Action: CHANGE_ADDED, Name: "a.example.org."}}); err == nil {
+		t.Fatalf("expected sending to a closed sink to fail")
+	}
+	sink.Close()
+}
+
+func TestWebhookSinkCloseTimeout(t *testing.T) {
+	// a receiver which doesn't answer until the test is done
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	sink := newWebhookSink(srv.URL)
+	sink.timeout = 50 * time.Millisecond
+	for i := 0; i < 3; i++ {
+		if err := sink.Send([]changeEvent{{Action: CHANGE_ADDED, Name: "a.example.org."}}); err != nil {
+			t.Fatalf("failed to send events: %s", err)
+		}
+	}
+	start := time.Now()
+	sink.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected close to give up after its timeout, took %s", elapsed)
+	}
+}
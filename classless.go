@@ -0,0 +1,70 @@
+package ospfip
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/pkg/dnsutil"
+	"github.com/miekg/dns"
+)
+
+// classlessZone is a reverse zone for an IPv4 network smaller than a /24, delegated as described in RFC 2317
+type classlessZone struct {
+	network *net.IPNet
+	name    string
+}
+
+// newClasslessZone returns the classless zone of a network, when no name is given
+// it is named after the range of the network, e.g. 0-31.100.51.198.in-addr.arpa. for 198.51.100.0/27
+func newClasslessZone(cidr, name string) (classlessZone, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return classlessZone{}, err
+	}
+	ones, bits := network.Mask.Size()
+	if bits != 8*net.IPv4len || ones <= 24 || ones >= 32 {
+		return classlessZone{}, fmt.Errorf("%q is not an IPv4 network between a /25 and a /31", cidr)
+	}
+	if name == "" {
+		first := network.IP.To4()[3]
+		last := first + byte(1<<(32-ones)-1)
+		name = fmt.Sprintf("%d-%d.%s", first, last, reverseZoneFor(network.IP))
+	}
+	name = plugin.Name(name).Normalize()
+	if !dns.IsSubDomain("in-addr.arpa.", name) {
+		return classlessZone{}, fmt.Errorf("%q is not a reverse zone", name)
+	}
+	return classlessZone{network: network, name: name}, nil
+}
+
+// return the name of the PTR record of an address within the zone
+func (c classlessZone) owner(ip net.IP) string {
+	return strconv.Itoa(int(ip.To4()[3])) + "." + c.name
+}
+
+// return the classless zone containing an address, if any
+func classlessZoneFor(zones []classlessZone, ip net.IP) (classlessZone, bool) {
+	for _, c := range zones {
+		if c.network.Contains(ip) {
+			return c, true
+		}
+	}
+	return classlessZone{}, false
+}
+
+// classlessAlias returns the CNAME of the classful reverse name of an address within a classless zone,
+// as published by the owner of the classful zone to delegate to the classless zone
+func classlessAlias(zones []classlessZone, qname string, ttl uint32) (*dns.CNAME, string) {
+	ip := net.ParseIP(dnsutil.ExtractAddressFromReverse(qname))
+	if ip == nil || ip.To4() == nil {
+		return nil, ""
+	}
+	c, ok := classlessZoneFor(zones, ip)
+	if !ok {
+		return nil, ""
+	}
+	hdr := dns.RR_Header{Name: qname, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: ttl}
+	return &dns.CNAME{Hdr: hdr, Target: c.owner(ip)}, c.name
+}
@@ -0,0 +1,100 @@
+package ospfip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+)
+
+func TestNewClasslessZone(t *testing.T) {
+	cases := []struct {
+		name     string
+		cidr     string
+		zone     string
+		expected string
+		valid    bool
+	}{
+		{name: "default name", cidr: "198.51.100.0/27", expected: "0-31.100.51.198.in-addr.arpa.", valid: true},
+		{name: "default name of upper range", cidr: "198.51.100.128/25", expected: "128-255.100.51.198.in-addr.arpa.", valid: true},
+		{name: "custom name", cidr: "198.51.100.32/27", zone: "32/27.100.51.198.in-addr.arpa", expected: "32/27.100.51.198.in-addr.arpa.", valid: true},
+		{name: "classful network", cidr: "198.51.100.0/24", valid: false},
+		{name: "ipv6 network", cidr: "2001:db8::/120", valid: false},
+		{name: "forward zone name", cidr: "198.51.100.0/27", zone: "example.net", valid: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newClasslessZone(tt.cidr, tt.zone)
+			if !tt.valid {
+				if err == nil {
+					t.Fatalf("expected %s to be invalid", tt.cidr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected %s to be valid, got %s", tt.cidr, err)
+			}
+			if got.name != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, got.name)
+			}
+		})
+	}
+}
+
+func TestServeDNSClasslessReverse(t *testing.T) {
+	classless, _ := newClasslessZone("198.51.100.0/27", "")
+	names := []string{classless.name, "100.51.198.in-addr.arpa."}
+	reverseRecords := map[string]string{
+		"198.51.100.5":   "api.example.net.",
+		"198.51.100.200": "www.example.net.",
+	}
+	reverseZones, err := buildConfiguredReverseZones(names, []classlessZone{classless}, reverseRecords, nil, 60)
+	if err != nil {
+		t.Fatalf("failed to build reverse zones: %s", err)
+	}
+	of := OspFip{
+		ttl:              60,
+		reverseZoneNames: names,
+		classless:        []classlessZone{classless},
+		reverseZones:     reverseZones,
+		Next:             test.NextHandler(dns.RcodeNameError, nil),
+	}
+
+	cases := []struct {
+		name     string
+		qname    string
+		expected []string
+		rcode    int
+	}{
+		{name: "classless name", qname: "5.0-31.100.51.198.in-addr.arpa.", expected: []string{"PTR"}, rcode: dns.RcodeSuccess},
+		{name: "classful name through the cname", qname: "5.100.51.198.in-addr.arpa.", expected: []string{"CNAME", "PTR"}, rcode: dns.RcodeSuccess},
+		{name: "unknown address within the classless zone", qname: "6.100.51.198.in-addr.arpa.", expected: []string{"CNAME"}, rcode: dns.RcodeNameError},
+		{name: "address outside the classless zone", qname: "200.100.51.198.in-addr.arpa.", expected: []string{"PTR"}, rcode: dns.RcodeSuccess},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			r := new(dns.Msg)
+			r.SetQuestion(tt.qname, dns.TypePTR)
+			if _, err := of.ServeDNS(context.TODO(), w, r); err != nil {
+				t.Fatal(err)
+			}
+			if w.Msg.Rcode != tt.rcode {
+				t.Fatalf("expected rcode %d, got %v", tt.rcode, w.Msg)
+			}
+			if len(w.Msg.Answer) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, w.Msg.Answer)
+			}
+			for i, rr := range w.Msg.Answer {
+				if dns.TypeToString[rr.Header().Rrtype] != tt.expected[i] {
+					t.Fatalf("expected %v, got %v", tt.expected, w.Msg.Answer)
+				}
+			}
+		})
+	}
+	if _, ok := reverseZones["100.51.198.in-addr.arpa."].Search("5.100.51.198.in-addr.arpa."); ok {
+		t.Fatalf("expected the address within the classless zone not to be published in the classful zone")
+	}
+}
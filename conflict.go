@@ -0,0 +1,135 @@
+package ospfip
+
+import (
+	"sort"
+)
+
+const (
+	CONFLICT_ROUNDROBIN = "roundrobin"
+	CONFLICT_OLDEST     = "oldest"
+	CONFLICT_NEWEST     = "newest"
+	CONFLICT_REJECT     = "reject"
+)
+
+var conflictPolicies = []string{CONFLICT_ROUNDROBIN, CONFLICT_OLDEST, CONFLICT_NEWEST, CONFLICT_REJECT}
+
+// conflict describes a name claimed by more than one floating ip
+type conflict struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Policy string   `json:"policy"`
+	FipIDs []string `json:"fip_ids"`
+	Served []string `json:"served"`
+}
+
+// return if the given conflict policy is known
+func validConflictPolicy(policy string) bool {
+	for _, p := range conflictPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveConflicts applies the conflict policy to records sharing the same name and type.
+// The order of the remaining records is preserved.
+func resolveConflicts(records []record, policy string) ([]record, []conflict) {
+	type key struct{ name, rrType string }
+	claims := make(map[key][]int)
+	keys := make([]key, 0)
+	for i, r := range records {
+		k := key{r.Name, r.Type}
+		if _, ok := claims[k]; !ok {
+			keys = append(keys, k)
+		}
+		claims[k] = append(claims[k], i)
+	}
+
+	drop := make(map[int]struct{})
+	conflicts := make([]conflict, 0)
+	for _, k := range keys {
+		idx := claims[k]
+		if len(idx) < 2 || sameServerGroup(records, idx) || sameLoadBalancer(records, idx) || prioritized(records, idx) {
+			continue
+		}
+		c := conflict{Name: k.name, Type: k.rrType, Policy: policy, FipIDs: make([]string, 0, len(idx)), Served: make([]string, 0)}
+		for _, i := range idx {
+			c.FipIDs = append(c.FipIDs, records[i].FipID)
+		}
+
+		keep := make(map[int]struct{})
+		switch policy {
+		case CONFLICT_OLDEST, CONFLICT_NEWEST:
+			sorted := append([]int{}, idx...)
+			sort.SliceStable(sorted, func(a, b int) bool {
+				ra, rb := records[sorted[a]], records[sorted[b]]
+				if !ra.CreatedAt.Equal(rb.CreatedAt) {
+					if policy == CONFLICT_OLDEST {
+						return ra.CreatedAt.Before(rb.CreatedAt)
+					}
+					return ra.CreatedAt.After(rb.CreatedAt)
+				}
+				return ra.FipID < rb.FipID
+			})
+			keep[sorted[0]] = struct{}{}
+		case CONFLICT_REJECT:
+		default:
+			for _, i := range idx {
+				keep[i] = struct{}{}
+			}
+		}
+		for _, i := range idx {
+			if _, ok := keep[i]; ok {
+				c.Served = append(c.Served, records[i].FipID)
+			} else {
+				drop[i] = struct{}{}
+			}
+		}
+		log.Warningf("'%s' (%s) is claimed by floating ips %v, serving %v according to the '%s' conflict policy", c.Name, c.Type, c.FipIDs, c.Served, policy)
+		conflicts = append(conflicts, c)
+	}
+
+	if len(drop) == 0 {
+		return records, conflicts
+	}
+	kept := make([]record, 0, len(records)-len(drop))
+	for i, r := range records {
+		if _, ok := drop[i]; !ok {
+			kept = append(kept, r)
+		}
+	}
+	return kept, conflicts
+}
+
+// return if the records are published for the members of the same server group, which share a name by design
+func sameServerGroup(records []record, idx []int) bool {
+	group := records[idx[0]].ServerGroup
+	for _, i := range idx {
+		if group == "" || records[i].ServerGroup != group {
+			return false
+		}
+	}
+	return true
+}
+
+// return if the records are the VIP of a load balancer and the floating ips associated with it
+func sameLoadBalancer(records []record, idx []int) bool {
+	lb := records[idx[0]].LoadBalancerID
+	for _, i := range idx {
+		if lb == "" || records[i].LoadBalancerID != lb {
+			return false
+		}
+	}
+	return true
+}
+
+// return if all records carry a priority, failover picks among them rather than the conflict policy
+func prioritized(records []record, idx []int) bool {
+	for _, i := range idx {
+		if records[i].Priority == nil {
+			return false
+		}
+	}
+	return true
+}
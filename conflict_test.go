@@ -0,0 +1,85 @@
+package ospfip
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResolveConflicts(t *testing.T) {
+	now := time.Now()
+	old := record{Name: "api.example.org.", Type: "A", IP: "192.0.2.1", FipID: "fip-old", CreatedAt: now.Add(-time.Hour)}
+	recent := record{Name: "api.example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-new", CreatedAt: now}
+	v6 := record{Name: "api.example.org.", Type: "AAAA", IP: "2001:db8::1", FipID: "fip-v6", CreatedAt: now}
+	other := record{Name: "db.example.org.", Type: "A", IP: "192.0.2.3", FipID: "fip-db", CreatedAt: now}
+
+	cases := []struct {
+		name           string
+		policy         string
+		expectedFips   []string
+		expectedServed []string
+	}{
+		{name: "round robin serves all", policy: CONFLICT_ROUNDROBIN, expectedFips: []string{"fip-old", "fip-v6", "fip-db", "fip-new"}, expectedServed: []string{"fip-old", "fip-new"}},
+		{name: "oldest wins", policy: CONFLICT_OLDEST, expectedFips: []string{"fip-old", "fip-v6", "fip-db"}, expectedServed: []string{"fip-old"}},
+		{name: "newest wins", policy: CONFLICT_NEWEST, expectedFips: []string{"fip-v6", "fip-db", "fip-new"}, expectedServed: []string{"fip-new"}},
+		{name: "reject serves none", policy: CONFLICT_REJECT, expectedFips: []string{"fip-v6", "fip-db"}, expectedServed: []string{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			records, conflicts := resolveConflicts([]record{old, v6, other, recent}, tt.policy)
+			got := make([]string, 0)
+			for _, r := range records {
+				got = append(got, r.FipID)
+			}
+			if !reflect.DeepEqual(got, tt.expectedFips) {
+				t.Fatalf("expected %v, got %v", tt.expectedFips, got)
+			}
+			if len(conflicts) != 1 {
+				t.Fatalf("expected a single conflict, got %+v", conflicts)
+			}
+			if !reflect.DeepEqual(conflicts[0].FipIDs, []string{"fip-old", "fip-new"}) {
+				t.Fatalf("expected conflicting fips [fip-old fip-new], got %v", conflicts[0].FipIDs)
+			}
+			if !reflect.DeepEqual(conflicts[0].Served, tt.expectedServed) {
+				t.Fatalf("expected served %v, got %v", tt.expectedServed, conflicts[0].Served)
+			}
+		})
+	}
+}
+
+func TestResolveConflictsExempt(t *testing.T) {
+	now := time.Now()
+	primary, backup := 0, 1
+	cases := []struct {
+		name    string
+		records []record
+	}{
+		{
+			name: "failover priorities",
+			records: []record{
+				{Name: "db.example.org.", Type: "A", IP: "192.0.2.1", FipID: "fip-primary", CreatedAt: now, Priority: &primary},
+				{Name: "db.example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-backup", CreatedAt: now.Add(-time.Hour), Priority: &backup},
+			},
+		},
+		{
+			name: "load balancer vip and floating ip",
+			records: []record{
+				{Name: "lb.example.org.", Type: "A", IP: "10.0.0.5", FipID: "lb-1", CreatedAt: now, LoadBalancerID: "lb-1"},
+				{Name: "lb.example.org.", Type: "A", IP: "192.0.2.5", FipID: "fip-vip", CreatedAt: now.Add(-time.Hour), LoadBalancerID: "lb-1"},
+			},
+		},
+	}
+	for _, tt := range cases {
+		for _, policy := range conflictPolicies {
+			t.Run(tt.name+"/"+policy, func(t *testing.T) {
+				records, conflicts := resolveConflicts(tt.records, policy)
+				if !reflect.DeepEqual(records, tt.records) {
+					t.Fatalf("expected all records to be kept, got %+v", records)
+				}
+				if len(conflicts) != 0 {
+					t.Fatalf("expected no conflicts, got %+v", conflicts)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package ospfip
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// snapshot is the JSON representation of the records currently served
+type snapshot struct {
+	Zones       []zoneSnapshot    `json:"zones"`
+	Reverse     map[string]string `json:"reverse"`
+	LastSync    *time.Time        `json:"last_sync,omitempty"`
+	LastSuccess *time.Time        `json:"last_success,omitempty"`
+	LastError   string            `json:"last_error,omitempty"`
+	Rejected    []rejection       `json:"rejected"`
+	Errors      []fipError        `json:"errors"`
+	Conflicts   []conflict        `json:"conflicts"`
+	Held        *heldSnapshot     `json:"held,omitempty"`
+	Health      map[string]bool   `json:"health,omitempty"`
+}
+
+type heldSnapshot struct {
+	Reason  string `json:"reason"`
+	Records int    `json:"records"`
+}
+
+type zoneSnapshot struct {
+	Name    string   `json:"name"`
+	Records []record `json:"records"`
+}
+
+// Snapshot returns a copy of the current state of the plugin.
+func (of *OspFip) Snapshot() snapshot {
+	of.mutex.RLock()
+	defer of.mutex.RUnlock()
+
+	s := snapshot{
+		Zones:     make([]zoneSnapshot, 0, len(of.zoneNames)),
+		Reverse:   make(map[string]string, len(of.reverseRecords)),
+		Rejected:  append([]rejection{}, of.rejected...),
+		Errors:    append([]fipError{}, of.fipErrors...),
+		Conflicts: append([]conflict{}, of.conflicts...),
+		Health:    of.health.status(),
+	}
+	byZone := make(map[string][]record)
+	for _, r := range of.records {
+		byZone[r.Zone] = append(byZone[r.Zone], r)
+	}
+	for _, name := range of.zoneNames {
+		s.Zones = append(s.Zones, zoneSnapshot{Name: name, Records: append([]record{}, byZone[name]...)})
+	}
+	sort.Slice(s.Zones, func(i, j int) bool { return s.Zones[i].Name < s.Zones[j].Name })
+	for addr, name := range of.reverseRecords {
+		s.Reverse[addr] = name
+	}
+	if !of.lastSync.IsZero() {
+		lastSync := of.lastSync
+		s.LastSync = &lastSync
+	}
+	if !of.lastSuccess.IsZero() {
+		lastSuccess := of.lastSuccess
+		s.LastSuccess = &lastSuccess
+	}
+	if of.lastSyncErr != nil {
+		s.LastError = of.lastSyncErr.Error()
+	}
+	if of.pending != nil {
+		s.Held = &heldSnapshot{Reason: of.pendingReason.Error(), Records: len(of.pending.records)}
+	}
+	return s
+}
+
+// debugServer exposes the snapshot of an OspFip as JSON over HTTP
+type debugServer struct {
+	addr string
+	of   *OspFip
+	ln   net.Listener
+	srv  *http.Server
+}
+
+func newDebugServer(addr string, of *OspFip) *debugServer {
+	return &debugServer{addr: addr, of: of}
+}
+
+func (d *debugServer) Start() error {
+	ln, err := net.Listen("tcp", d.addr)
+	if err != nil {
+		return err
+	}
+	d.ln = ln
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.ServeHTTP)
+	mux.HandleFunc("/confirm", d.handleConfirm)
+	d.srv = &http.Server{Handler: mux, ReadTimeout: 5 * time.Second, WriteTimeout: 5 * time.Second}
+
+	go func() {
+		if err := d.srv.Serve(d.ln); err != nil && err != http.ErrServerClosed {
+			log.Errorf("debug endpoint on %s stopped: %v", d.addr, err)
+		}
+	}()
+	log.Infof("serving debug snapshot on %s", d.ln.Addr())
+	return nil
+}
+
+func (d *debugServer) Stop() error {
+	if d.srv == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return d.srv.Shutdown(ctx)
+}
+
+func (d *debugServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(d.of.Snapshot()); err != nil {
+		log.Errorf("failed to encode debug snapshot: %v", err)
+	}
+}
+
+// handleConfirm publishes a sync held back by the mass-deletion protection
+func (d *debugServer) handleConfirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if err := d.of.ConfirmPending(); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package ospfip
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const invalidTaggedFip = `
+{
+        "id": "a4f1c0d2-3b1e-4f0a-9d53-7e0b2c4f9a11",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "192.0.0.6",
+        "fixed_ip_address": "192.168.0.6",
+        "status": "ACTIVE",
+        "tags": [
+          "coredns:plugin:ospfip",
+          "coredns:plugin:ospfip:example_net"
+        ]
+}`
+
+func TestDebugServeHTTP(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(taggedFip, invalidTaggedFip))
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.Origins = []string{"."}
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+
+	ds := newDebugServer("127.0.0.1:0", of)
+	w := httptest.NewRecorder()
+	ds.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var got snapshot
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode snapshot: %s", err)
+	}
+	if len(got.Zones) != 1 || got.Zones[0].Name != "mycluster.example.net." {
+		t.Fatalf("expected zone 'mycluster.example.net.', got %+v", got.Zones)
+	}
+	records := got.Zones[0].Records
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %+v", records)
+	}
+	expected := record{
+		Name:      "api.mycluster.example.net.",
+		Type:      "A",
+		IP:        "192.0.0.3",
+		FipID:     "49426401-21ef-4314-a5ca-05423f4405ad",
+		Tag:       "coredns:plugin:ospfip:api.mycluster.example.net",
+		ProjectID: "eac7ae24f17790eec436bd46c71834d8",
+		Status:    "DOWN",
+		FixedIP:   "192.168.0.3",
+	}
+	if !reflect.DeepEqual(records[0], expected) {
+		t.Fatalf("expected record %+v, got %+v", expected, records[0])
+	}
+	if got.Reverse["192.0.0.3"] != "api.mycluster.example.net." {
+		t.Fatalf("expected reverse mapping for 192.0.0.3, got %+v", got.Reverse)
+	}
+	if got.LastSuccess == nil || got.LastError != "" {
+		t.Fatalf("expected successful sync, got %v (%s)", got.LastSuccess, got.LastError)
+	}
+	if len(got.Rejected) != 1 || got.Rejected[0].Tag != "coredns:plugin:ospfip:example_net" {
+		t.Fatalf("expected rejected tag 'coredns:plugin:ospfip:example_net', got %+v", got.Rejected)
+	}
+
+	w = httptest.NewRecorder()
+	ds.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package ospfip
+
+import (
+	"context"
+	"net"
+	"sort"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/miekg/dns"
+)
+
+const (
+	DISCOVER_SUBNETS     = "subnets"
+	DISCOVER_SUBNETPOOLS = "subnetpools"
+)
+
+// discoverReverseZones returns the reverse zones of the subnets of the floating networks of the given floating ips,
+// or of the subnet pools behind them
+func (of *OspFip) discoverReverseZones(ctx context.Context, fips []floatingips.FloatingIP) ([]string, error) {
+	networks := make(map[string]struct{})
+	for _, fip := range fips {
+		if fip.FloatingNetworkID != "" {
+			networks[fip.FloatingNetworkID] = struct{}{}
+		}
+	}
+
+	cidrs := make([]string, 0)
+	pools := make(map[string]struct{})
+	for network := range networks {
+		subnets, err := of.client.ListSubnets(ctx, network)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range subnets {
+			if of.discover == DISCOVER_SUBNETPOOLS && s.SubnetPoolID != "" {
+				pools[s.SubnetPoolID] = struct{}{}
+				continue
+			}
+			cidrs = append(cidrs, s.CIDR)
+		}
+	}
+	for pool := range pools {
+		prefixes, err := of.client.SubnetPoolPrefixes(ctx, pool)
+		if err != nil {
+			return nil, err
+		}
+		cidrs = append(cidrs, prefixes...)
+	}
+	return minimalReverseZones(cidrs), nil
+}
+
+// minimalReverseZones returns the smallest set of reverse zones covering the given networks,
+// networks are split on octet or nibble boundaries and zones within other zones are left out
+func minimalReverseZones(cidrs []string) []string {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		if _, n, err := net.ParseCIDR(c); err == nil {
+			networks = append(networks, n)
+		}
+	}
+
+	names := make(map[string]struct{})
+	for _, n := range networks {
+		for _, name := range plugin.Host(n.String()).NormalizeExact() {
+			names[name] = struct{}{}
+		}
+	}
+	zones := make([]string, 0, len(names))
+	for name := range names {
+		covered := false
+		for other := range names {
+			if other != name && dns.IsSubDomain(other, name) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			zones = append(zones, name)
+		}
+	}
+	sort.Strings(zones)
+	return zones
+}
+
+// the reverse zones which are served, both configured and discovered
+func (of *OspFip) servedReverseZones() []string {
+	of.mutex.RLock()
+	defer of.mutex.RUnlock()
+	if len(of.discoveredZones) == 0 {
+		return of.reverseZoneNames
+	}
+	return append(append([]string{}, of.reverseZoneNames...), of.discoveredZones...)
+}
@@ -0,0 +1,104 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const externalFip = `
+{
+        "id": "2f245a7b-796b-4f26-9cf9-9e82d248fda7",
+        "floating_network_id": "376da547-b977-4cfe-9cba-275c80debf57",
+        "floating_ip_address": "198.51.100.5",
+        "status": "ACTIVE",
+        "tags": [
+          "coredns:plugin:ospfip",
+          "coredns:plugin:ospfip:api.example.net"
+        ]
+}`
+
+const externalSubnets = `
+{
+    "subnets": [
+        {"id": "subnet-v4", "network_id": "376da547-b977-4cfe-9cba-275c80debf57", "cidr": "198.51.100.0/24", "subnetpool_id": "pool-v4"},
+        {"id": "subnet-v6", "network_id": "376da547-b977-4cfe-9cba-275c80debf57", "cidr": "2001:db8:0:1::/64"}
+    ]
+}`
+
+func TestMinimalReverseZones(t *testing.T) {
+	cases := []struct {
+		name     string
+		cidrs    []string
+		expected []string
+	}{
+		{name: "single network", cidrs: []string{"198.51.100.0/24"}, expected: []string{"100.51.198.in-addr.arpa."}},
+		{name: "network within another", cidrs: []string{"198.51.100.0/24", "198.51.0.0/16"}, expected: []string{"51.198.in-addr.arpa."}},
+		{name: "network off an octet boundary", cidrs: []string{"198.51.100.0/23"}, expected: []string{"100.51.198.in-addr.arpa.", "101.51.198.in-addr.arpa."}},
+		{name: "duplicate networks", cidrs: []string{"2001:db8::/32", "2001:db8::/32"}, expected: []string{"8.b.d.0.1.0.0.2.ip6.arpa."}},
+		{name: "invalid network", cidrs: []string{"not-a-network"}, expected: []string{}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := minimalReverseZones(tt.cidrs); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDiscoverReverseZones(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(externalFip))
+	})
+	th.Mux.HandleFunc("/v2.0/subnets", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("network_id") != "376da547-b977-4cfe-9cba-275c80debf57" {
+			t.Fatalf("expected subnets to be filtered by network, got %s", r.URL.RawQuery)
+		}
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, externalSubnets)
+	})
+	th.Mux.HandleFunc("/v2.0/subnetpools/pool-v4", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"subnetpool": {"id": "pool-v4", "prefixes": ["198.51.0.0/16"], "default_prefixlen": 24, "min_prefixlen": 8, "max_prefixlen": 32, "ip_version": 4}}`)
+	})
+
+	cases := []struct {
+		discover string
+		expected []string
+	}{
+		{discover: DISCOVER_SUBNETS, expected: []string{"1.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", "100.51.198.in-addr.arpa."}},
+		{discover: DISCOVER_SUBNETPOOLS, expected: []string{"1.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", "51.198.in-addr.arpa."}},
+	}
+	for _, tt := range cases {
+		t.Run(tt.discover, func(t *testing.T) {
+			of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+			of.Origins = []string{"."}
+			of.discover = tt.discover
+			if err := of.updateRecords(context.TODO()); err != nil {
+				t.Fatalf("failed to update records: %s", err)
+			}
+			if got := of.servedReverseZones(); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+			for _, name := range tt.expected {
+				if _, ok := of.reverseZones[name]; !ok {
+					t.Fatalf("expected reverse zone %s to be built, got %v", name, of.reverseZones)
+				}
+			}
+		})
+	}
+}
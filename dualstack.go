@@ -0,0 +1,66 @@
+package ospfip
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+)
+
+// return if an address is a global IPv6 address, unique local and link local addresses are not
+func isGlobalIPv6(ip net.IP) bool {
+	return ip != nil && ip.To4() == nil && ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// dualStackRecords returns the AAAA records pairing an A record with the global IPv6 addresses of its port
+func (of *OspFip) dualStackRecords(r record, addresses []string) []record {
+	paired := make([]record, 0)
+	for _, addr := range addresses {
+		ip := net.ParseIP(addr)
+		if !isGlobalIPv6(ip) {
+			continue
+		}
+		aaaa := r
+		aaaa.Type = "AAAA"
+		aaaa.IP = ip.String()
+		aaaa.FixedIP = ip.String()
+		// the typed records are published along with the A record
+		aaaa.Typed = nil
+		aaaa.PTR = ""
+		if strings.HasPrefix(r.Name, "*.") && of.wildcardPTR != "" {
+			aaaa.PTR = wildcardPTRTarget(r.Name, of.wildcardPTR, ip)
+		}
+		paired = append(paired, aaaa)
+	}
+	return paired
+}
+
+// add the AAAA records of the global IPv6 addresses of the ports of floating ips
+// the ports are listed in batches, failing to list them doesn't affect the A records
+func (of *OspFip) addDualStack(ctx context.Context, records []record) []record {
+	if !of.dualStack {
+		return records
+	}
+	ids := make([]string, 0)
+	for _, r := range records {
+		if r.Type == "A" && r.PortID != "" && !r.Sinkholed && !slices.Contains(ids, r.PortID) {
+			ids = append(ids, r.PortID)
+		}
+	}
+	if len(ids) == 0 {
+		return records
+	}
+	addresses, err := of.client.PortFixedIPs(ctx, ids)
+	if err != nil {
+		log.Warningf("%v", err)
+		return records
+	}
+	paired := make([]record, 0)
+	for _, r := range records {
+		if r.Type != "A" || r.PortID == "" || r.Sinkholed {
+			continue
+		}
+		paired = append(paired, of.dualStackRecords(r, addresses[r.PortID])...)
+	}
+	return append(records, paired...)
+}
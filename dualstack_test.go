@@ -0,0 +1,121 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const associatedFip = `
+{
+        "id": "7c5b8e2a-4f3d-4e61-9b1a-3d2c6f8e9a10",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "198.51.100.7",
+        "fixed_ip_address": "10.0.0.7",
+        "port_id": "ce705c24-c1ef-408a-bda3-7bbd946164ab",
+        "status": "ACTIVE",
+        "tags": [
+          "coredns:plugin:ospfip",
+          "coredns:plugin:ospfip:db.example.net"
+        ]
+}`
+
+const associatedPorts = `
+{
+    "ports": [
+        {
+            "id": "ce705c24-c1ef-408a-bda3-7bbd946164ab",
+            "fixed_ips": [
+                {"subnet_id": "subnet-v4", "ip_address": "10.0.0.7"},
+                {"subnet_id": "subnet-v6", "ip_address": "2001:db8:1::7"},
+                {"subnet_id": "subnet-ula", "ip_address": "fd00::7"}
+            ]
+        }
+    ]
+}`
+
+func TestIsGlobalIPv6(t *testing.T) {
+	cases := []struct {
+		ip     string
+		global bool
+	}{
+		{ip: "2001:db8:1::7", global: true},
+		{ip: "fd00::7", global: false},
+		{ip: "fe80::1", global: false},
+		{ip: "10.0.0.7", global: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isGlobalIPv6(net.ParseIP(tt.ip)); got != tt.global {
+				t.Fatalf("expected %v, got %v", tt.global, got)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsDualStack(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(associatedFip))
+	})
+	lists := 0
+	th.Mux.HandleFunc("/v2.0/ports", func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		if got := r.URL.Query()["id"]; len(got) != 1 || got[0] != "ce705c24-c1ef-408a-bda3-7bbd946164ab" {
+			t.Errorf("expected the port of the floating ip to be listed, got %v", got)
+		}
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, associatedPorts)
+	})
+
+	cases := []struct {
+		name      string
+		dualStack bool
+		records   int
+		lists     int
+	}{
+		{name: "disabled", dualStack: false, records: 1, lists: 0},
+		{name: "enabled", dualStack: true, records: 2, lists: 1},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+			of.Origins = []string{"."}
+			of.dualStack = tt.dualStack
+			lists = 0
+			if err := of.updateRecords(context.TODO()); err != nil {
+				t.Fatalf("failed to update records: %s", err)
+			}
+			if lists != tt.lists {
+				t.Fatalf("expected ports listed %d time(s), got %d", tt.lists, lists)
+			}
+			if len(of.records) != tt.records {
+				t.Fatalf("expected %d records, got %+v", tt.records, of.records)
+			}
+			if !tt.dualStack {
+				return
+			}
+			aaaa := of.records[1]
+			if aaaa.Type != "AAAA" || aaaa.IP != "2001:db8:1::7" || aaaa.Name != "db.example.net." {
+				t.Fatalf("expected AAAA record for the global IPv6 address, got %+v", aaaa)
+			}
+			if of.reverseRecords["2001:db8:1::7"] != "db.example.net." {
+				t.Fatalf("expected PTR record for the IPv6 address, got %+v", of.reverseRecords)
+			}
+			if _, ok := of.zones["example.net."].Search("db.example.net."); !ok {
+				t.Fatalf("expected db.example.net. to be published")
+			}
+		})
+	}
+}
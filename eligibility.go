@@ -0,0 +1,66 @@
+package ospfip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// eligibility decides which floating ips are published based on their state in Neutron
+type eligibility struct {
+	// the status a floating ip needs to have, empty allows any status
+	status string
+	// whether a floating ip needs to be associated with a port
+	association bool
+	// addresses answered instead of the floating ip when it isn't eligible, per address family
+	sinkholeV4 net.IP
+	sinkholeV6 net.IP
+}
+
+// reason a record is not eligible, empty if it is
+func (e eligibility) ineligible(r record) string {
+	if e.status != "" && !strings.EqualFold(r.Status, e.status) {
+		return fmt.Sprintf("status %q is not %q", r.Status, e.status)
+	}
+	if e.association && r.PortID == "" {
+		return "not associated with a port"
+	}
+	return ""
+}
+
+// sinkhole address for a record type, nil when none is configured
+func (e eligibility) sinkhole(rrType string) net.IP {
+	if rrType == "AAAA" {
+		return e.sinkholeV6
+	}
+	return e.sinkholeV4
+}
+
+// apply drops ineligible records or points them to the sinkhole address
+// returns the remaining records, the skipped ones as rejections and the number of sinkholed records
+func (e eligibility) apply(records []record) ([]record, []rejection, int) {
+	if e.status == "" && !e.association {
+		return records, nil, 0
+	}
+	kept := make([]record, 0, len(records))
+	skipped := make([]rejection, 0)
+	sinkholed := 0
+	for _, r := range records {
+		reason := e.ineligible(r)
+		if reason == "" {
+			kept = append(kept, r)
+			continue
+		}
+		if addr := e.sinkhole(r.Type); addr != nil {
+			log.Debugf("answering '%s' with sinkhole %s instead of floating ip %s: %s", r.Name, addr, r.FipID, reason)
+			r.IP = addr.String()
+			r.Sinkholed = true
+			kept = append(kept, r)
+			sinkholed++
+			continue
+		}
+		log.Debugf("skipping floating ip %s: %s", r.FipID, reason)
+		skipped = append(skipped, rejection{FipID: r.FipID, Tag: r.Tag, Reason: reason})
+	}
+	return kept, skipped, sinkholed
+}
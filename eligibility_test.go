@@ -0,0 +1,54 @@
+package ospfip
+
+import (
+	"net"
+	"testing"
+)
+
+func TestEligibilityApply(t *testing.T) {
+	active := record{Name: "a.example.org.", Type: "A", IP: "192.0.2.1", FipID: "fip-1", Status: "ACTIVE", PortID: "port-1"}
+	down := record{Name: "b.example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-2", Status: "DOWN", PortID: "port-2"}
+	unassociated := record{Name: "c.example.org.", Type: "AAAA", IP: "2001:db8::3", FipID: "fip-3", Status: "ACTIVE"}
+
+	cases := []struct {
+		name              string
+		eligibility       eligibility
+		expectedFips      []string
+		expectedSkipped   int
+		expectedSinkholed int
+	}{
+		{name: "no requirements", eligibility: eligibility{}, expectedFips: []string{"fip-1", "fip-2", "fip-3"}},
+		{name: "require status", eligibility: eligibility{status: "ACTIVE"}, expectedFips: []string{"fip-1", "fip-3"}, expectedSkipped: 1},
+		{name: "require association", eligibility: eligibility{association: true}, expectedFips: []string{"fip-1", "fip-2"}, expectedSkipped: 1},
+		{name: "require both", eligibility: eligibility{status: "active", association: true}, expectedFips: []string{"fip-1"}, expectedSkipped: 2},
+		{
+			name:              "sinkhole per address family",
+			eligibility:       eligibility{status: "ACTIVE", association: true, sinkholeV4: net.ParseIP("192.0.2.254")},
+			expectedFips:      []string{"fip-1", "fip-2"},
+			expectedSkipped:   1,
+			expectedSinkholed: 1,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			records, skipped, sinkholed := tt.eligibility.apply([]record{active, down, unassociated})
+			if len(records) != len(tt.expectedFips) {
+				t.Fatalf("expected %v, got %+v", tt.expectedFips, records)
+			}
+			for i, r := range records {
+				if r.FipID != tt.expectedFips[i] {
+					t.Fatalf("expected %v, got %+v", tt.expectedFips, records)
+				}
+				if r.Sinkholed && r.IP != tt.eligibility.sinkholeV4.String() {
+					t.Fatalf("expected sinkholed record to point to %s, got %s", tt.eligibility.sinkholeV4, r.IP)
+				}
+			}
+			if len(skipped) != tt.expectedSkipped {
+				t.Fatalf("expected %d skipped, got %+v", tt.expectedSkipped, skipped)
+			}
+			if sinkholed != tt.expectedSinkholed {
+				t.Fatalf("expected %d sinkholed, got %d", tt.expectedSinkholed, sinkholed)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package ospfip
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/miekg/dns"
+)
+
+const EXPORT_FILE_PREFIX = "db."
+
+// exporter writes zones as RFC 1035 zone files to a directory
+type exporter struct {
+	dir string
+	// files written by the previous export, used to clean up zones which disappeared
+	written map[string]struct{}
+}
+
+func newExporter(dir string) *exporter {
+	return &exporter{dir: dir, written: make(map[string]struct{})}
+}
+
+// Export writes a file per zone and removes the files of zones which are no longer present.
+// Files are only rewritten when their content changed.
+func (e *exporter) Export(zones map[string]*file.Zone) error {
+	written := make(map[string]struct{}, len(zones))
+	var errs []string
+
+	for name, z := range zones {
+		path := filepath.Join(e.dir, zoneFileName(name))
+		written[path] = struct{}{}
+		changed, err := writeFileIfChanged(path, zoneFileContent(name, z))
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if changed {
+			log.Debugf("exported zone '%s' to %s", name, path)
+		}
+	}
+	for path := range e.written {
+		if _, ok := written[path]; ok {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err.Error())
+			continue
+		}
+		log.Debugf("removed zone file %s", path)
+	}
+	e.written = written
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to export zones: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// return the file name used for a zone
+func zoneFileName(zone string) string {
+	return EXPORT_FILE_PREFIX + strings.TrimSuffix(zone, ".")
+}
+
+// render a zone in RFC 1035 presentation format
+// records are sorted so unchanged zones render identically
+func zoneFileContent(name string, z *file.Zone) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "$ORIGIN %s\n", dns.Fqdn(name))
+
+	apex, _ := z.ApexIfDefined()
+	for _, rr := range apex {
+		fmt.Fprintln(&b, rr.String())
+	}
+	for _, elem := range z.Tree.All() {
+		rrs := make([]string, 0)
+		for _, rr := range elem.All() {
+			rrs = append(rrs, rr.String())
+		}
+		sort.Strings(rrs)
+		for _, rr := range rrs {
+			fmt.Fprintln(&b, rr)
+		}
+	}
+	return b.Bytes()
+}
+
+// write content to path using an atomic rename, unless the file already holds that content
+func writeFileIfChanged(path string, content []byte) (bool, error) {
+	current, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(current, content) {
+		return false, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// build reverse zones holding the PTR records for the given address to name mapping
+// IPv4 addresses are grouped per /24, IPv6 addresses per /64
+func reverseZonesFromRecords(reverseRecords map[string]string, ttls map[string]uint32, ttl uint32) (map[string]*file.Zone, error) {
+	return buildReverseZones(reverseRecords, ttls, ttl, func(reverse string, ip net.IP) (string, string) { return reverse, reverseZoneFor(ip) })
+}
+
+// return the classful reverse zone an IP belongs to
+func reverseZoneFor(ip net.IP) string {
+	reverse, _ := dns.ReverseAddr(ip.String())
+	labels := dns.SplitDomainName(reverse)
+	strip := 1
+	if ip.To4() == nil {
+		strip = 16
+	}
+	return dns.Fqdn(strings.Join(labels[strip:], "."))
+}
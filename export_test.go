@@ -0,0 +1,94 @@
+package ospfip
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/miekg/dns"
+)
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	e := newExporter(dir)
+
+	zone := file.NewZone("example.org.", "")
+	zone.Insert(soaFromOrigin("example.org.", 3600)[0])
+	rr, _ := dns.NewRR("a.example.org. 3600 IN A 192.0.2.1")
+	zone.Insert(rr)
+
+	if err := e.Export(map[string]*file.Zone{"example.org.": zone}); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+	path := filepath.Join(dir, "db.example.org")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected zone file %s: %s", path, err)
+	}
+	parsed, err := file.Parse(strings.NewReader(string(content)), "example.org.", path, 0)
+	if err != nil {
+		t.Fatalf("failed to parse exported zone: %s\n%s", err, content)
+	}
+	if parsed.Len() != 1 {
+		t.Fatalf("expected 1 record in exported zone, got %d", parsed.Len())
+	}
+
+	// an unchanged zone must not be rewritten
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(path, past, past)
+	if err := e.Export(map[string]*file.Zone{"example.org.": zone}); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+	fi, _ := os.Stat(path)
+	if !fi.ModTime().Equal(past) {
+		t.Fatalf("expected unchanged zone file to be left alone, modified at %v", fi.ModTime())
+	}
+
+	// zones which disappeared are removed
+	if err := e.Export(map[string]*file.Zone{}); err != nil {
+		t.Fatalf("failed to export: %s", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected zone file %s to be removed, got %v", path, err)
+	}
+}
+
+func TestReverseZoneFor(t *testing.T) {
+	cases := []struct {
+		name     string
+		ip       net.IP
+		expected string
+	}{
+		{name: "IPv4 address", ip: net.ParseIP("192.0.2.1"), expected: "2.0.192.in-addr.arpa."},
+		{name: "IPv6 address", ip: net.ParseIP("2001:db8::1"), expected: "0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reverseZoneFor(tt.ip)
+			if got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReverseZonesFromRecords(t *testing.T) {
+	zones, err := reverseZonesFromRecords(map[string]string{
+		"192.0.2.1":   "a.example.org.",
+		"192.0.2.2":   "b.example.org.",
+		"2001:db8::1": "c.example.org.",
+	}, nil, 3600)
+	if err != nil {
+		t.Fatalf("failed to build reverse zones: %s", err)
+	}
+	if len(zones) != 2 {
+		t.Fatalf("expected 2 reverse zones, got %d", len(zones))
+	}
+	if z := zones["2.0.192.in-addr.arpa."]; z == nil || z.Len() != 2 {
+		t.Fatalf("expected 2 PTR records in 2.0.192.in-addr.arpa., got %+v", z)
+	}
+}
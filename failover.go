@@ -0,0 +1,93 @@
+package ospfip
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+const STATUS_ACTIVE = "ACTIVE"
+
+// failover only serves the addresses with the lowest priority number that are available for names
+// with a priority set, switching to the next priority when none of them is available.
+// An address is available when its floating ip is ACTIVE in Neutron and passes its health check, if any.
+type failover struct {
+	mutex sync.RWMutex
+	// the priority currently served per name
+	selected map[recordKey]int
+	// the records of names with a priority which are not served
+	excluded map[recordKey]struct{}
+}
+
+func newFailover() *failover {
+	return &failover{selected: make(map[recordKey]int), excluded: make(map[recordKey]struct{})}
+}
+
+// update selects the priority to serve for every name, logging and counting the changes
+func (f *failover) update(records []record, healthy func(name, ip string) bool) {
+	if f == nil {
+		return
+	}
+	groups := make(map[recordKey][]record)
+	for _, r := range records {
+		if r.Priority == nil {
+			continue
+		}
+		k := recordKey{name: r.Name, rrType: r.Type}
+		groups[k] = append(groups[k], r)
+	}
+
+	selected := make(map[recordKey]int, len(groups))
+	excluded := make(map[recordKey]struct{})
+	for k, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return *group[i].Priority < *group[j].Priority })
+		priority := *group[0].Priority
+		for _, r := range group {
+			if !r.Sinkholed && strings.EqualFold(r.Status, STATUS_ACTIVE) && healthy(r.Name, r.IP) {
+				priority = *r.Priority
+				break
+			}
+		}
+		selected[k] = priority
+		for _, r := range group {
+			if *r.Priority != priority {
+				excluded[keyOf(r)] = struct{}{}
+			}
+		}
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for k, priority := range selected {
+		if previous, ok := f.selected[k]; ok && previous != priority {
+			log.Warningf("failover of '%s' (%s) from priority %d to %d", k.name, k.rrType, previous, priority)
+			failoverCount.Inc()
+		}
+	}
+	f.selected = selected
+	f.excluded = excluded
+}
+
+// filter removes the A and AAAA records which are not served from an answer
+func (f *failover) filter(answer []dns.RR) []dns.RR {
+	if f == nil {
+		return answer
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	if len(f.excluded) == 0 {
+		return answer
+	}
+	filtered := make([]dns.RR, 0, len(answer))
+	for _, rr := range answer {
+		if addressOf(rr) != nil {
+			if _, ok := f.excluded[keyOfRR(rr)]; ok {
+				continue
+			}
+		}
+		filtered = append(filtered, rr)
+	}
+	return filtered
+}
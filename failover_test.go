@@ -0,0 +1,69 @@
+package ospfip
+
+import (
+	"testing"
+
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+)
+
+func TestFailoverUpdate(t *testing.T) {
+	primary, backup := 0, 10
+	records := []record{
+		{Name: "db.example.org.", Type: "A", IP: "192.0.2.1", Status: "ACTIVE", Priority: &primary},
+		{Name: "db.example.org.", Type: "A", IP: "192.0.2.2", Status: "ACTIVE", Priority: &backup},
+		{Name: "www.example.org.", Type: "A", IP: "192.0.2.3", Status: "DOWN"},
+	}
+	answer := []dns.RR{
+		test.A("db.example.org. 60 IN A 192.0.2.1"),
+		test.A("db.example.org. 60 IN A 192.0.2.2"),
+	}
+
+	cases := []struct {
+		name      string
+		status    string
+		unhealthy string
+		expected  []string
+	}{
+		{name: "primary available", status: "ACTIVE", expected: []string{"192.0.2.1"}},
+		{name: "primary down in neutron", status: "DOWN", expected: []string{"192.0.2.2"}},
+		{name: "primary failing health check", status: "ACTIVE", unhealthy: "192.0.2.1", expected: []string{"192.0.2.2"}},
+		{name: "nothing available serves primary", status: "DOWN", unhealthy: "192.0.2.2", expected: []string{"192.0.2.1"}},
+	}
+	f := newFailover()
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			records[0].Status = tt.status
+			f.update(records, func(name, ip string) bool { return ip != tt.unhealthy })
+			got := make([]string, 0)
+			for _, rr := range f.filter(answer) {
+				got = append(got, rr.(*dns.A).A.String())
+			}
+			if len(got) != len(tt.expected) || got[0] != tt.expected[0] {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if got := f.filter([]dns.RR{test.A("www.example.org. 60 IN A 192.0.2.3")}); len(got) != 1 {
+		t.Fatalf("expected address without priority to be served, got %v", got)
+	}
+
+	// an excluded address is still served for other names sharing it
+	records[0].Status = "DOWN"
+	f.update(records, func(name, ip string) bool { return true })
+	if got := f.filter([]dns.RR{test.A("sinkhole.example.org. 60 IN A 192.0.2.1")}); len(got) != 1 {
+		t.Fatalf("expected address excluded for another name to be served, got %v", got)
+	}
+}
+
+func TestAttributesFromTagsPriority(t *testing.T) {
+	attrs, rejected := attributesFromTags([]string{"coredns:plugin:ospfip:priority=5"})
+	if attrs.priority == nil || *attrs.priority != 5 || len(rejected) != 0 {
+		t.Fatalf("expected priority 5, got %+v %+v", attrs, rejected)
+	}
+	attrs, rejected = attributesFromTags([]string{"coredns:plugin:ospfip:priority=-1"})
+	if attrs.priority != nil || len(rejected) != 1 {
+		t.Fatalf("expected invalid priority to be rejected, got %+v %+v", attrs, rejected)
+	}
+}
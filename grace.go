@@ -0,0 +1,71 @@
+package ospfip
+
+import (
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+)
+
+// removalGrace keeps serving records of floating ips which disappeared from the listing for a while,
+// to bridge e.g. a floating ip swap where the new one isn't tagged yet
+type removalGrace struct {
+	period time.Duration
+	// the maximum TTL of records within their grace period, 0 keeps the TTL of the record
+	ttl uint32
+	// the records seen during previous syncs by name, type and IP
+	seen map[recordKey]record
+}
+
+type recordKey struct{ name, rrType, ip string }
+
+func keyOf(r record) recordKey { return recordKey{r.Name, r.Type, r.IP} }
+
+// apply marks the current records as seen and returns them together with the records
+// of floating ips which are no longer listed but were seen within the grace period.
+// A record within its grace period is dropped as soon as its name is served by another floating ip,
+// its TTL is lowered to the grace TTL but never raised above the TTL it would otherwise be served with.
+func (g *removalGrace) apply(records []record, listed []floatingips.FloatingIP, ttl uint32, now time.Time) []record {
+	if g == nil || g.period <= 0 {
+		return records
+	}
+	if g.seen == nil {
+		g.seen = make(map[recordKey]record)
+	}
+
+	fipIDs := make(map[string]struct{}, len(listed))
+	for _, fip := range listed {
+		fipIDs[fip.ID] = struct{}{}
+	}
+	current := make(map[recordKey]struct{}, len(records))
+	names := make(map[recordKey]struct{}, len(records))
+	for i := range records {
+		records[i].LastSeen = now
+		k := keyOf(records[i])
+		current[k] = struct{}{}
+		names[recordKey{name: k.name, rrType: k.rrType}] = struct{}{}
+		g.seen[k] = records[i]
+	}
+
+	for k, r := range g.seen {
+		if _, ok := current[k]; ok {
+			continue
+		}
+		_, listed := fipIDs[r.FipID]
+		_, replaced := names[recordKey{name: k.name, rrType: k.rrType}]
+		if listed || replaced || now.Sub(r.LastSeen) > g.period {
+			delete(g.seen, k)
+			continue
+		}
+		log.Debugf("serving '%s' from floating ip %s within its grace period, last seen %s", r.Name, r.FipID, r.LastSeen.Format(time.RFC3339))
+		r.Stale = true
+		effective := ttl
+		if r.TTL > 0 {
+			effective = r.TTL
+		}
+		if g.ttl > 0 && g.ttl < effective {
+			r.TTL = g.ttl
+		}
+		records = append(records, r)
+	}
+	return records
+}
@@ -0,0 +1,66 @@
+package ospfip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+)
+
+func TestRemovalGraceApply(t *testing.T) {
+	api := record{Name: "api.example.org.", Type: "A", IP: "192.0.2.1", FipID: "fip-1"}
+	apiNew := record{Name: "api.example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-2"}
+	db := record{Name: "db.example.org.", Type: "A", IP: "192.0.2.3", FipID: "fip-3"}
+
+	now := time.Now()
+	g := &removalGrace{period: time.Minute, ttl: 10}
+	g.apply([]record{api, db}, []floatingips.FloatingIP{{ID: "fip-1"}, {ID: "fip-3"}}, 300, now)
+
+	// fip-1 disappeared, within the grace period its record is kept with the reduced TTL
+	got := g.apply([]record{db}, []floatingips.FloatingIP{{ID: "fip-3"}}, 300, now.Add(30*time.Second))
+	if len(got) != 2 {
+		t.Fatalf("expected record of fip-1 to be kept, got %+v", got)
+	}
+	stale := got[1]
+	if stale.FipID != "fip-1" || !stale.Stale || stale.TTL != 10 || !stale.LastSeen.Equal(now) {
+		t.Fatalf("expected stale record of fip-1 with ttl 10 last seen at %v, got %+v", now, stale)
+	}
+
+	// after the grace period the record is dropped
+	got = g.apply([]record{db}, []floatingips.FloatingIP{{ID: "fip-3"}}, 300, now.Add(2*time.Minute))
+	if len(got) != 1 {
+		t.Fatalf("expected record of fip-1 to be dropped, got %+v", got)
+	}
+
+	// a name served by another floating ip doesn't keep the old one
+	g = &removalGrace{period: time.Minute}
+	g.apply([]record{api}, []floatingips.FloatingIP{{ID: "fip-1"}}, 300, now)
+	got = g.apply([]record{apiNew}, []floatingips.FloatingIP{{ID: "fip-2"}}, 300, now.Add(time.Second))
+	if len(got) != 1 || got[0].FipID != "fip-2" {
+		t.Fatalf("expected only fip-2 to be served, got %+v", got)
+	}
+
+	// a floating ip which is still listed (e.g. rejected) doesn't get a grace period
+	g = &removalGrace{period: time.Minute}
+	g.apply([]record{api}, []floatingips.FloatingIP{{ID: "fip-1"}}, 300, now)
+	got = g.apply([]record{}, []floatingips.FloatingIP{{ID: "fip-1"}}, 300, now.Add(time.Second))
+	if len(got) != 0 {
+		t.Fatalf("expected no records for listed floating ip, got %+v", got)
+	}
+
+	// the grace TTL never raises the TTL a record is served with
+	g = &removalGrace{period: time.Minute, ttl: 300}
+	g.apply([]record{api, db}, []floatingips.FloatingIP{{ID: "fip-1"}, {ID: "fip-3"}}, 60, now)
+	got = g.apply([]record{db}, []floatingips.FloatingIP{{ID: "fip-3"}}, 60, now.Add(time.Second))
+	if len(got) != 2 || got[1].TTL != 0 {
+		t.Fatalf("expected stale record of fip-1 to keep the default ttl, got %+v", got)
+	}
+	apiTTL := api
+	apiTTL.TTL = 3600
+	g = &removalGrace{period: time.Minute, ttl: 300}
+	g.apply([]record{apiTTL, db}, []floatingips.FloatingIP{{ID: "fip-1"}, {ID: "fip-3"}}, 60, now)
+	got = g.apply([]record{db}, []floatingips.FloatingIP{{ID: "fip-3"}}, 60, now.Add(time.Second))
+	if len(got) != 2 || got[1].TTL != 300 {
+		t.Fatalf("expected stale record of fip-1 with ttl 300, got %+v", got)
+	}
+}
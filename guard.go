@@ -0,0 +1,66 @@
+package ospfip
+
+import (
+	"fmt"
+)
+
+// removalGuard holds back syncs which would remove a suspicious amount of records,
+// e.g. when the OpenStack API returns an empty or truncated list
+type removalGuard struct {
+	// the percentage of the published records a sync may remove, 100 disables the check
+	maxRemovalPercent int
+	// the minimum number of records a sync must result in, 0 disables the check
+	minRecords int
+}
+
+// check returns an error describing why the current records should not replace the previous ones
+func (g removalGuard) check(previous, current []record) error {
+	if g.minRecords > 0 && len(current) < g.minRecords {
+		return fmt.Errorf("sync held back: %d record(s) is below the minimum of %d", len(current), g.minRecords)
+	}
+	if len(previous) == 0 || g.maxRemovalPercent >= 100 {
+		return nil
+	}
+	type key struct{ name, rrType, ip string }
+	remaining := make(map[key]struct{}, len(current))
+	for _, r := range current {
+		remaining[key{r.Name, r.Type, r.IP}] = struct{}{}
+	}
+	removed := 0
+	for _, r := range previous {
+		if _, ok := remaining[key{r.Name, r.Type, r.IP}]; !ok {
+			removed++
+		}
+	}
+	if removed*100 > g.maxRemovalPercent*len(previous) {
+		return fmt.Errorf("sync held back: it would remove %d of %d record(s), more than %d%%", removed, len(previous), g.maxRemovalPercent)
+	}
+	return nil
+}
+
+// hold keeps the previous records published and stores the outcome of the sync until it is confirmed
+func (of *OspFip) hold(state *syncState, reason error) {
+	of.mutex.Lock()
+	of.pending = state
+	of.pendingReason = reason
+	of.mutex.Unlock()
+	heldSync.Set(1)
+	heldSyncCount.Inc()
+	log.Warningf("%v, keeping the previous records until the condition clears or it is confirmed", reason)
+}
+
+// ConfirmPending publishes a sync which was held back.
+func (of *OspFip) ConfirmPending() error {
+	// a sync publishing in the meantime clears the held back one, it must not be overwritten
+	of.publishMutex.Lock()
+	defer of.publishMutex.Unlock()
+	of.mutex.RLock()
+	state := of.pending
+	of.mutex.RUnlock()
+	if state == nil {
+		return fmt.Errorf("no sync is held back")
+	}
+	log.Infof("publishing held back sync with %d record(s) after confirmation", len(state.records))
+	of.publishLocked(state)
+	return nil
+}
@@ -0,0 +1,100 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRemovalGuardCheck(t *testing.T) {
+	a := record{Name: "a.example.org.", Type: "A", IP: "192.0.2.1"}
+	b := record{Name: "b.example.org.", Type: "A", IP: "192.0.2.2"}
+	c := record{Name: "c.example.org.", Type: "A", IP: "192.0.2.3"}
+	d := record{Name: "d.example.org.", Type: "A", IP: "192.0.2.4"}
+
+	cases := []struct {
+		name     string
+		guard    removalGuard
+		previous []record
+		current  []record
+		held     bool
+	}{
+		{name: "disabled guard allows removing everything", guard: removalGuard{maxRemovalPercent: 100}, previous: []record{a, b}, current: []record{}, held: false},
+		{name: "removal within threshold", guard: removalGuard{maxRemovalPercent: 25}, previous: []record{a, b, c, d}, current: []record{a, b, c}, held: false},
+		{name: "removal above threshold", guard: removalGuard{maxRemovalPercent: 25}, previous: []record{a, b, c, d}, current: []record{a, b}, held: true},
+		{name: "additions don't count as removals", guard: removalGuard{maxRemovalPercent: 0}, previous: []record{a}, current: []record{a, b}, held: false},
+		{name: "below minimum records", guard: removalGuard{maxRemovalPercent: 100, minRecords: 2}, previous: []record{a, b}, current: []record{a}, held: true},
+		{name: "at minimum records", guard: removalGuard{maxRemovalPercent: 100, minRecords: 2}, previous: []record{a}, current: []record{a, b}, held: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.guard.check(tt.previous, tt.current)
+			if tt.held && err == nil {
+				t.Fatalf("expected sync to be held back")
+			}
+			if !tt.held && err != nil {
+				t.Fatalf("expected sync not to be held back, got %s", err)
+			}
+		})
+	}
+}
+
+func TestHeldSyncKeepsPreviousRecords(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	response := ListResponse(taggedFip)
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, response)
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.Origins = []string{"."}
+	of.guard = removalGuard{maxRemovalPercent: 50}
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+
+	response = ListResponse("")
+	if err := of.updateRecords(context.TODO()); err == nil {
+		t.Fatalf("expected sync removing all records to be held back")
+	}
+	if len(of.records) != 1 {
+		t.Fatalf("expected previous records to be kept, got %+v", of.records)
+	}
+	if of.Snapshot().Held == nil {
+		t.Fatalf("expected held sync in snapshot")
+	}
+	// the metrics describe the served records, not the held back ones
+	if got := testutil.ToFloat64(recordCount); got != 1 {
+		t.Fatalf("expected records metric of the served sync, got %v", got)
+	}
+
+	ds := newDebugServer("127.0.0.1:0", of)
+	w := httptest.NewRecorder()
+	ds.handleConfirm(w, httptest.NewRequest("POST", "/confirm", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if len(of.records) != 0 || of.pending != nil {
+		t.Fatalf("expected held sync to be published after confirmation, got %+v", of.records)
+	}
+	if got := testutil.ToFloat64(recordCount); got != 0 {
+		t.Fatalf("expected records metric of the confirmed sync, got %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	ds.handleConfirm(w, httptest.NewRequest("POST", "/confirm", nil))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d without held sync, got %d", http.StatusConflict, w.Code)
+	}
+}
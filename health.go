@@ -0,0 +1,282 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	HEALTH_TCP  = "tcp"
+	HEALTH_HTTP = "http"
+
+	DEFAULT_HEALTH_INTERVAL = 10 * time.Second
+	DEFAULT_HEALTH_TIMEOUT  = 2 * time.Second
+	// consecutive failed probes before an address is considered unhealthy
+	HEALTH_FAILS = 2
+)
+
+// healthCheck probes the addresses of the names matching a pattern
+type healthCheck struct {
+	pattern namePattern
+	kind    string
+	port    int
+	path    string
+}
+
+func newHealthCheck(pattern, kind, port, path string) (healthCheck, error) {
+	p, err := newNamePattern(pattern)
+	if err != nil {
+		return healthCheck{}, err
+	}
+	if kind != HEALTH_TCP && kind != HEALTH_HTTP {
+		return healthCheck{}, fmt.Errorf("unknown health check %q, expected %q or %q", kind, HEALTH_TCP, HEALTH_HTTP)
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum < 1 || portNum > 65535 {
+		return healthCheck{}, fmt.Errorf("invalid port %q", port)
+	}
+	if kind == HEALTH_TCP && path != "" {
+		return healthCheck{}, fmt.Errorf("a path is only supported for %q health checks", HEALTH_HTTP)
+	}
+	if kind == HEALTH_HTTP && path == "" {
+		path = "/"
+	}
+	return healthCheck{pattern: p, kind: kind, port: portNum, path: path}, nil
+}
+
+// healthTarget is an address probed by a health check
+type healthTarget struct {
+	ip    string
+	check healthCheck
+}
+
+// healthKey identifies the health of an address for the names covered by a check,
+// an address shared by names of different checks is probed by each of them
+type healthKey struct {
+	pattern namePattern
+	ip      string
+}
+
+func (t healthTarget) key() healthKey { return healthKey{pattern: t.check.pattern, ip: t.ip} }
+
+// healthChecker periodically probes the published addresses which are covered by a health check
+type healthChecker struct {
+	checks   []healthCheck
+	interval time.Duration
+	timeout  time.Duration
+
+	mutex   sync.RWMutex
+	targets map[healthKey]healthTarget
+	// consecutive failed probes per check and address
+	fails map[healthKey]int
+	// called after every round of probes
+	onProbed func()
+}
+
+func newHealthChecker(checks []healthCheck, interval, timeout time.Duration) *healthChecker {
+	return &healthChecker{
+		checks:   checks,
+		interval: interval,
+		timeout:  timeout,
+		targets:  make(map[healthKey]healthTarget),
+		fails:    make(map[healthKey]int),
+	}
+}
+
+// return the check covering a name, false when no check matches it
+func (h *healthChecker) checkOf(name string) (healthCheck, bool) {
+	patterns := make([]namePattern, 0, len(h.checks))
+	for _, c := range h.checks {
+		patterns = append(patterns, c.pattern)
+	}
+	if i := mostSpecific(patterns, name); i >= 0 {
+		return h.checks[i], true
+	}
+	return healthCheck{}, false
+}
+
+// update the probed addresses from the published records
+func (h *healthChecker) update(records []record) {
+	if h == nil {
+		return
+	}
+	targets := make(map[healthKey]healthTarget)
+	for _, r := range records {
+		if r.Sinkholed {
+			continue
+		}
+		if check, ok := h.checkOf(r.Name); ok {
+			t := healthTarget{ip: r.IP, check: check}
+			targets[t.key()] = t
+		}
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.targets = targets
+	for k := range h.fails {
+		if _, ok := targets[k]; !ok {
+			delete(h.fails, k)
+		}
+	}
+}
+
+// run probes all targets every interval until ctx is done
+func (h *healthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		h.probeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthChecker) probeAll(ctx context.Context) {
+	h.mutex.RLock()
+	targets := make([]healthTarget, 0, len(h.targets))
+	for _, t := range h.targets {
+		targets = append(targets, t)
+	}
+	h.mutex.RUnlock()
+
+	results := make(map[healthKey]error, len(targets))
+	var wg sync.WaitGroup
+	var rmutex sync.Mutex
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t healthTarget) {
+			defer wg.Done()
+			err := h.probe(ctx, t)
+			rmutex.Lock()
+			results[t.key()] = err
+			rmutex.Unlock()
+		}(t)
+	}
+	wg.Wait()
+
+	unhealthy := 0
+	h.mutex.Lock()
+	for k, err := range results {
+		if _, ok := h.targets[k]; !ok {
+			continue
+		}
+		if err == nil {
+			if h.fails[k] >= HEALTH_FAILS {
+				log.Infof("%s is healthy again for '%s'", k.ip, k.pattern)
+			}
+			h.fails[k] = 0
+			continue
+		}
+		h.fails[k]++
+		if h.fails[k] == HEALTH_FAILS {
+			log.Warningf("%s is unhealthy for '%s': %v", k.ip, k.pattern, err)
+		}
+	}
+	for _, n := range h.fails {
+		if n >= HEALTH_FAILS {
+			unhealthy++
+		}
+	}
+	h.mutex.Unlock()
+	unhealthyCount.Set(float64(unhealthy))
+	if h.onProbed != nil {
+		h.onProbed()
+	}
+}
+
+// probe a single target, returns nil when it is healthy
+func (h *healthChecker) probe(ctx context.Context, t healthTarget) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	addr := net.JoinHostPort(t.ip, strconv.Itoa(t.check.port))
+
+	switch t.check.kind {
+	case HEALTH_HTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+t.check.path, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil
+	default:
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// healthy returns false when the address of a name failed the check covering the name,
+// addresses of names without a check are healthy
+func (h *healthChecker) healthy(name, ip string) bool {
+	if h == nil {
+		return true
+	}
+	check, ok := h.checkOf(name)
+	if !ok {
+		return true
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.fails[healthKey{pattern: check.pattern, ip: ip}] < HEALTH_FAILS
+}
+
+// filter removes A and AAAA records of unhealthy addresses from an answer,
+// unless none of them is healthy in which case the answer is returned as is
+func (h *healthChecker) filter(answer []dns.RR) []dns.RR {
+	if h == nil {
+		return answer
+	}
+	filtered := make([]dns.RR, 0, len(answer))
+	addresses, healthy := 0, 0
+	for _, rr := range answer {
+		ip := addressOf(rr)
+		if ip == nil {
+			filtered = append(filtered, rr)
+			continue
+		}
+		addresses++
+		if h.healthy(rr.Header().Name, ip.String()) {
+			healthy++
+			filtered = append(filtered, rr)
+		}
+	}
+	if addresses > 0 && healthy == 0 {
+		return answer
+	}
+	return filtered
+}
+
+// status of the probed addresses for introspection, by pattern and address
+func (h *healthChecker) status() map[string]bool {
+	if h == nil {
+		return nil
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	status := make(map[string]bool, len(h.targets))
+	for k := range h.targets {
+		status[string(k.pattern)+" "+k.ip] = h.fails[k] < HEALTH_FAILS
+	}
+	return status
+}
@@ -0,0 +1,169 @@
+package ospfip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+)
+
+func TestHealthCheckerProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	port := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+
+	check, err := newHealthCheck("example.org", HEALTH_TCP, port, "")
+	if err != nil {
+		t.Fatalf("failed to create health check: %s", err)
+	}
+	h := newHealthChecker([]healthCheck{check}, time.Minute, time.Second)
+	h.update([]record{
+		{Name: "up.example.org.", IP: "127.0.0.1"},
+		{Name: "down.example.org.", IP: "127.0.0.2"},
+		{Name: "unchecked.example.net.", IP: "127.0.0.3"},
+	})
+
+	for i := 0; i < HEALTH_FAILS; i++ {
+		h.probeAll(context.TODO())
+	}
+	if !h.healthy("up.example.org.", "127.0.0.1") {
+		t.Fatalf("expected 127.0.0.1 to be healthy")
+	}
+	if h.healthy("down.example.org.", "127.0.0.2") {
+		t.Fatalf("expected 127.0.0.2 to be unhealthy")
+	}
+	if !h.healthy("unchecked.example.net.", "127.0.0.3") {
+		t.Fatalf("expected address without health check to be healthy")
+	}
+	if status := h.status(); len(status) != 2 {
+		t.Fatalf("expected status of 2 addresses, got %+v", status)
+	}
+
+	// an address shared with a name without a check stays healthy for that name
+	h.update([]record{
+		{Name: "down.example.org.", IP: "127.0.0.2"},
+		{Name: "shared.example.net.", IP: "127.0.0.2"},
+	})
+	if h.healthy("down.example.org.", "127.0.0.2") {
+		t.Fatalf("expected 127.0.0.2 to be unhealthy for the checked name")
+	}
+	if !h.healthy("shared.example.net.", "127.0.0.2") {
+		t.Fatalf("expected 127.0.0.2 to be healthy for the name without health check")
+	}
+}
+
+func TestHealthCheckerHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	cases := []struct {
+		name    string
+		path    string
+		healthy bool
+	}{
+		{name: "healthy endpoint", path: "/healthz", healthy: true},
+		{name: "failing endpoint", path: "/missing", healthy: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := newHealthCheck("example.org", HEALTH_HTTP, port, tt.path)
+			if err != nil {
+				t.Fatalf("failed to create health check: %s", err)
+			}
+			h := newHealthChecker([]healthCheck{check}, time.Minute, time.Second)
+			err = h.probe(context.TODO(), healthTarget{ip: "127.0.0.1", check: check})
+			if tt.healthy && err != nil {
+				t.Fatalf("expected probe to succeed, got %s", err)
+			}
+			if !tt.healthy && err == nil {
+				t.Fatalf("expected probe to fail")
+			}
+		})
+	}
+}
+
+func TestHealthCheckerFilter(t *testing.T) {
+	check, err := newHealthCheck("app.example.org", HEALTH_TCP, "443", "")
+	if err != nil {
+		t.Fatalf("failed to create health check: %s", err)
+	}
+	h := newHealthChecker([]healthCheck{check}, time.Minute, time.Second)
+	h.fails[healthKey{pattern: check.pattern, ip: "192.0.2.2"}] = HEALTH_FAILS
+
+	answer := []dns.RR{
+		test.A("app.example.org. 60 IN A 192.0.2.1"),
+		test.A("app.example.org. 60 IN A 192.0.2.2"),
+	}
+	if got := h.filter(answer); len(got) != 1 || got[0].(*dns.A).A.String() != "192.0.2.1" {
+		t.Fatalf("expected only the healthy address, got %v", got)
+	}
+
+	// the address is only unhealthy for the names covered by the check
+	other := []dns.RR{
+		test.A("other.example.org. 60 IN A 192.0.2.1"),
+		test.A("other.example.org. 60 IN A 192.0.2.2"),
+	}
+	if got := h.filter(other); len(got) != 2 {
+		t.Fatalf("expected all addresses of a name without health check, got %v", got)
+	}
+
+	h.fails[healthKey{pattern: check.pattern, ip: "192.0.2.1"}] = HEALTH_FAILS
+	if got := h.filter(answer); len(got) != 2 {
+		t.Fatalf("expected all addresses when none is healthy, got %v", got)
+	}
+
+	var disabled *healthChecker
+	if got := disabled.filter(answer); len(got) != 2 {
+		t.Fatalf("expected all addresses without health checks, got %v", got)
+	}
+}
+
+func TestNewHealthCheck(t *testing.T) {
+	cases := []struct {
+		name  string
+		kind  string
+		port  string
+		path  string
+		valid bool
+	}{
+		{name: "tcp check", kind: HEALTH_TCP, port: "443", valid: true},
+		{name: "http check with default path", kind: HEALTH_HTTP, port: "80", valid: true},
+		{name: "unknown kind", kind: "icmp", port: "80", valid: false},
+		{name: "invalid port", kind: HEALTH_TCP, port: "70000", valid: false},
+		{name: "tcp check with path", kind: HEALTH_TCP, port: "443", path: "/", valid: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newHealthCheck("example.org", tt.kind, tt.port, tt.path)
+			if tt.valid && err != nil {
+				t.Fatalf("expected valid health check, got %s", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatalf("expected invalid health check")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package ospfip
+
+import (
+	"context"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/loadbalancer/v2/loadbalancers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+)
+
+// return the status of a load balancer as floating ip status, it is ACTIVE when it is provisioned and serving
+func loadBalancerStatus(lb loadbalancers.LoadBalancer) string {
+	if lb.ProvisioningStatus != STATUS_ACTIVE {
+		return lb.ProvisioningStatus
+	}
+	switch lb.OperatingStatus {
+	case "ONLINE", "DEGRADED", "NO_MONITOR":
+		return STATUS_ACTIVE
+	default:
+		return lb.OperatingStatus
+	}
+}
+
+// vipAsFip describes the VIP of a load balancer as a floating ip so it is processed with the same tag grammar
+func vipAsFip(lb loadbalancers.LoadBalancer) floatingips.FloatingIP {
+	return floatingips.FloatingIP{
+		ID:         lb.ID,
+		FloatingIP: lb.VipAddress,
+		PortID:     lb.VipPortID,
+		ProjectID:  lb.ProjectID,
+		Status:     loadBalancerStatus(lb),
+		Tags:       lb.Tags,
+		CreatedAt:  lb.CreatedAt,
+		UpdatedAt:  lb.UpdatedAt,
+	}
+}
+
+// loadBalancerRecords returns the records of the VIPs of the tagged load balancers and of the floating ips associated with them.
+// Floating ips which are tagged themselves are left to the floating ip source.
+func (of *OspFip) loadBalancerRecords(ctx context.Context, taggedFips []floatingips.FloatingIP) ([]record, []rejection, []fipError, error) {
+	lbs, err := of.client.ListTaggedLoadBalancers(ctx, PLUGIN_TAG_IDENTIFIER)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tagged := make(map[string]struct{}, len(taggedFips))
+	for _, fip := range taggedFips {
+		tagged[fip.ID] = struct{}{}
+	}
+
+	records := make([]record, 0, len(lbs))
+	rejected := make([]rejection, 0)
+	fipErrors := make([]fipError, 0)
+	for _, lb := range lbs {
+		vip, rejectedTags, err := of.recordFromFip(vipAsFip(lb))
+		rejected = append(rejected, rejectedTags...)
+		if err != nil {
+			log.Debugf("skipping load balancer %s: %v", lb.ID, err)
+			fipErrors = append(fipErrors, fipError{FipID: lb.ID, Error: err.Error()})
+			continue
+		}
+		if vip == nil {
+			continue
+		}
+		vip.LoadBalancerID = lb.ID
+		records = append(records, *vip)
+
+		if lb.VipPortID == "" {
+			continue
+		}
+		fips, err := of.client.PortFloatingIPs(ctx, lb.VipPortID)
+		if err != nil {
+			fipErrors = append(fipErrors, fipError{FipID: lb.ID, Name: vip.Name, Error: err.Error()})
+			continue
+		}
+		for _, fip := range fips {
+			if _, ok := tagged[fip.ID]; ok {
+				continue
+			}
+			fip.Tags = lb.Tags
+			r, _, err := of.recordFromFip(fip)
+			if err != nil || r == nil {
+				continue
+			}
+			r.LoadBalancerID = lb.ID
+			// the typed records are published along with the VIP
+			r.Typed = nil
+			records = append(records, *r)
+		}
+	}
+	return records, rejected, fipErrors, nil
+}
+
+// return the load balancer records of the last sync
+func (of *OspFip) previousLoadBalancerRecords() []record {
+	of.mutex.RLock()
+	defer of.mutex.RUnlock()
+	records := make([]record, 0)
+	for _, r := range of.records {
+		if r.LoadBalancerID != "" {
+			records = append(records, r)
+		}
+	}
+	return records
+}
@@ -0,0 +1,176 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/loadbalancer/v2/loadbalancers"
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const taggedLoadBalancers = `
+{
+    "loadbalancers": [
+        {
+            "id": "36e08a3e-a78f-4b40-a229-1e7e23eee1ab",
+            "project_id": "eac7ae24f17790eec436bd46c71834d8",
+            "vip_address": "10.30.176.47",
+            "vip_port_id": "2a22e552-a347-44fd-b530-1f2b1b2a6735",
+            "provisioning_status": "ACTIVE",
+            "operating_status": "ONLINE",
+            "tags": ["coredns:plugin:ospfip", "coredns:plugin:ospfip:lb.example.net", "coredns:plugin:ospfip:ttl=30"]
+        },
+        {
+            "id": "8e4e3b0c-3ae5-4a4c-9c2b-4a4b8d1f7e21",
+            "project_id": "eac7ae24f17790eec436bd46c71834d8",
+            "vip_address": "203.0.113.10",
+            "provisioning_status": "ACTIVE",
+            "operating_status": "ERROR",
+            "tags": ["coredns:plugin:ospfip", "coredns:plugin:ospfip:provider.example.net"]
+        }
+    ]
+}`
+
+const vipFloatingIPs = `
+{
+    "floatingips": [
+        {
+            "id": "a1e3e8c4-5b51-4b7f-8f0e-0c7c6a0c9b12",
+            "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+            "floating_ip_address": "198.51.100.47",
+            "fixed_ip_address": "10.30.176.47",
+            "port_id": "2a22e552-a347-44fd-b530-1f2b1b2a6735",
+            "status": "ACTIVE",
+            "tags": []
+        }
+    ]
+}`
+
+func TestLoadBalancerStatus(t *testing.T) {
+	cases := []struct {
+		provisioning string
+		operating    string
+		expected     string
+	}{
+		{provisioning: "ACTIVE", operating: "ONLINE", expected: STATUS_ACTIVE},
+		{provisioning: "ACTIVE", operating: "DEGRADED", expected: STATUS_ACTIVE},
+		{provisioning: "ACTIVE", operating: "OFFLINE", expected: "OFFLINE"},
+		{provisioning: "PENDING_UPDATE", operating: "ONLINE", expected: "PENDING_UPDATE"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.provisioning+"/"+tt.operating, func(t *testing.T) {
+			lb := loadbalancers.LoadBalancer{ProvisioningStatus: tt.provisioning, OperatingStatus: tt.operating}
+			if got := loadBalancerStatus(lb); got != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsLoadBalancers(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("port_id") == "2a22e552-a347-44fd-b530-1f2b1b2a6735" {
+			fmt.Fprint(w, vipFloatingIPs)
+			return
+		}
+		fmt.Fprint(w, ListResponse(taggedFip))
+	})
+	th.Mux.HandleFunc("/v2.0/lbaas/loadbalancers", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tags") != PLUGIN_TAG_IDENTIFIER {
+			t.Fatalf("expected load balancers to be filtered by tag, got %s", r.URL.RawQuery)
+		}
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, taggedLoadBalancers)
+	})
+
+	expected := map[string]struct {
+		name   string
+		status string
+		ttl    uint32
+	}{
+		"192.0.0.3":     {name: "api.mycluster.example.net.", status: "DOWN"},
+		"10.30.176.47":  {name: "lb.example.net.", status: STATUS_ACTIVE, ttl: 30},
+		"198.51.100.47": {name: "lb.example.net.", status: STATUS_ACTIVE, ttl: 30},
+		"203.0.113.10":  {name: "provider.example.net.", status: "ERROR"},
+	}
+	// the VIP and its floating ip share a name by design, whatever the conflict policy
+	for _, policy := range conflictPolicies {
+		t.Run(policy, func(t *testing.T) {
+			of := New(&OpenStackClient{client: fake.ServiceClient(), loadbalancer: fake.ServiceClient()}, 5*time.Minute, 5)
+			of.Origins = []string{"."}
+			of.loadBalancers = true
+			of.conflictPolicy = policy
+			if err := of.updateRecords(context.TODO()); err != nil {
+				t.Fatalf("failed to update records: %s", err)
+			}
+
+			if len(of.records) != len(expected) {
+				t.Fatalf("expected %d records, got %+v", len(expected), of.records)
+			}
+			for _, r := range of.records {
+				e, ok := expected[r.IP]
+				if !ok || r.Name != e.name || r.Status != e.status || r.TTL != e.ttl {
+					t.Fatalf("expected %+v for %s, got %+v", e, r.IP, r)
+				}
+				if r.Name != "api.mycluster.example.net." && r.LoadBalancerID == "" {
+					t.Fatalf("expected load balancer id on %+v", r)
+				}
+			}
+			if len(of.conflicts) != 0 {
+				t.Fatalf("expected no conflicts, got %+v", of.conflicts)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsLoadBalancersUnavailable(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("port_id") == "2a22e552-a347-44fd-b530-1f2b1b2a6735" {
+			fmt.Fprint(w, vipFloatingIPs)
+			return
+		}
+		fmt.Fprint(w, ListResponse(taggedFip))
+	})
+	available := true
+	th.Mux.HandleFunc("/v2.0/lbaas/loadbalancers", func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, taggedLoadBalancers)
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient(), loadbalancer: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.Origins = []string{"."}
+	of.loadBalancers = true
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+	synced := len(of.records)
+
+	// an Octavia outage doesn't hold back the floating ips and keeps the load balancer records
+	available = false
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("expected the sync to succeed without Octavia, got %s", err)
+	}
+	if len(of.records) != synced {
+		t.Fatalf("expected %d records to be kept, got %+v", synced, of.records)
+	}
+}
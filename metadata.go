@@ -0,0 +1,32 @@
+package ospfip
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// the label of the companion TXT record describing the floating ip of a name
+const METADATA_LABEL = "_ospfip"
+
+// metadataRecord returns the companion TXT record of a name identifying the floating ip it originates from
+func metadataRecord(name string, fip floatingips.FloatingIP) (typedRecord, bool) {
+	// a wildcard can't have a companion record
+	if validation.IsWildcardDNS1123Subdomain(unFqdn(name)) == nil {
+		return typedRecord{}, false
+	}
+	updated := fip.UpdatedAt
+	if updated.IsZero() {
+		updated = fip.CreatedAt
+	}
+	data := fmt.Sprintf("%q %q %q %q %q",
+		"fip_id="+fip.ID,
+		"project_id="+projectOf(fip),
+		"port_id="+fip.PortID,
+		"status="+fip.Status,
+		"updated_at="+updated.UTC().Format(time.RFC3339),
+	)
+	return typedRecord{Name: METADATA_LABEL + "." + name, Type: "TXT", Data: data, Tag: "metadata"}, true
+}
@@ -0,0 +1,74 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/miekg/dns"
+)
+
+func TestMetadataTXT(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(taggedFip, taggedWildcardFip))
+	})
+
+	cases := []struct {
+		name    string
+		enabled bool
+	}{
+		{name: "disabled by default", enabled: false},
+		{name: "enabled", enabled: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+			of.Origins = []string{"."}
+			of.Next = test.NextHandler(dns.RcodeNameError, nil)
+			of.metadataTXT = tt.enabled
+			if err := of.updateRecords(context.TODO()); err != nil {
+				t.Fatalf("failed to update records: %s", err)
+			}
+
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			r := new(dns.Msg)
+			r.SetQuestion("_ospfip.api.mycluster.example.net.", dns.TypeTXT)
+			rc, err := of.ServeDNS(context.TODO(), w, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.enabled {
+				if rc != dns.RcodeNameError {
+					t.Fatalf("expected no metadata record, got %v", w.Msg)
+				}
+				return
+			}
+			if w.Msg == nil || len(w.Msg.Answer) != 1 {
+				t.Fatalf("expected a single TXT record, got %v", w.Msg)
+			}
+			txt := strings.Join(w.Msg.Answer[0].(*dns.TXT).Txt, " ")
+			for _, expected := range []string{"fip_id=49426401-21ef-4314-a5ca-05423f4405ad", "project_id=eac7ae24f17790eec436bd46c71834d8", "port_id=", "status=DOWN", "updated_at="} {
+				if !strings.Contains(txt, expected) {
+					t.Fatalf("expected %q in %q", expected, txt)
+				}
+			}
+			for _, r := range of.records {
+				if r.Name == "*.mycluster.example.net." && len(r.Typed) != 0 {
+					t.Fatalf("expected no metadata record for a wildcard, got %+v", r.Typed)
+				}
+			}
+		})
+	}
+}
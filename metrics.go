@@ -0,0 +1,102 @@
+package ospfip
+
+import (
+	"github.com/coredns/coredns/plugin"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Variables declared for monitoring.
+var (
+	syncCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "syncs_total",
+		Help:      "Counter of record syncs with the OpenStack API per result.",
+	}, []string{"result"})
+
+	recordCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "records",
+		Help:      "Number of records published by the last sync.",
+	})
+
+	fipErrorCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "fip_errors",
+		Help:      "Number of floating ips skipped due to errors during the last sync.",
+	})
+
+	fipErrorTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "fip_errors_total",
+		Help:      "Counter of floating ips skipped due to errors.",
+	})
+
+	conflictCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "conflicts",
+		Help:      "Number of names claimed by more than one floating ip during the last sync.",
+	})
+
+	unauthorizedCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "unauthorized",
+		Help:      "Number of floating ips rejected by the ownership policy during the last sync.",
+	})
+
+	heldSync = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "sync_held",
+		Help:      "Whether the last sync was held back by the mass-deletion protection (1) or not (0).",
+	})
+
+	heldSyncCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "syncs_held_total",
+		Help:      "Counter of syncs held back by the mass-deletion protection.",
+	})
+
+	ineligibleCount = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "ineligible",
+		Help:      "Number of floating ips not eligible due to their status or association during the last sync, per action taken.",
+	}, []string{"action"})
+
+	unhealthyCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "unhealthy",
+		Help:      "Number of published addresses failing their health check.",
+	})
+
+	failoverCount = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: plugin.Namespace,
+		Subsystem: PLUGIN_NAME,
+		Name:      "failovers_total",
+		Help:      "Counter of changes of the served priority of a name.",
+	})
+)
+
+// update the metrics with the outcome of the published sync
+func reportSync(records, errors, conflicts, unauthorized int) {
+	recordCount.Set(float64(records))
+	conflictCount.Set(float64(conflicts))
+	unauthorizedCount.Set(float64(unauthorized))
+	fipErrorCount.Set(float64(errors))
+}
+
+// update the metrics with the number of ineligible floating ips of the published sync
+func reportIneligible(skipped, sinkholed int) {
+	ineligibleCount.WithLabelValues("skipped").Set(float64(skipped))
+	ineligibleCount.WithLabelValues("sinkholed").Set(float64(sinkholed))
+}
@@ -0,0 +1,195 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// the server metadata key overriding the name of a server
+	NOVA_HOSTNAME_METADATA = "ospfip_hostname"
+	// how long ports and servers are cached before they are looked up again
+	NOVA_CACHE_TTL = 10 * time.Minute
+)
+
+// novaNames names floating ips after the Nova server their port is attached to
+type novaNames struct {
+	domain string
+	// consider every floating ip, not only the ones carrying the identifier tag
+	all     bool
+	ttl     time.Duration
+	fetched time.Time
+	// the device of each port, empty for ports which no longer exist
+	devices map[string]string
+	servers map[string]servers.Server
+	// the names resolved during the last sync by floating ip id
+	names map[string]string
+	// publish a round robin name for the floating ips of the members of server groups
+	groups      bool
+	memberships map[string]membership
+}
+
+func newNovaNames(domain string, all bool) (*novaNames, error) {
+	domain = plugin.Name(domain).Normalize()
+	if errs := validation.IsDNS1123Subdomain(unFqdn(domain)); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid domain %q: %s", domain, strings.Join(errs, ", "))
+	}
+	return &novaNames{domain: domain, all: all, ttl: NOVA_CACHE_TTL}, nil
+}
+
+// return the tag floating ips are listed with, none when every floating ip is considered
+func (n *novaNames) listTag() string {
+	if n != nil && n.all {
+		return ""
+	}
+	return PLUGIN_TAG_IDENTIFIER
+}
+
+// return if a floating ip was only listed because every floating ip is considered and didn't get a name
+func (n *novaNames) skip(fip floatingips.FloatingIP) bool {
+	return n != nil && n.all && !hasIdentifierTag(fip) && n.names[fip.ID] == ""
+}
+
+// nameOf returns the name resolved for a floating ip during the last sync
+func (n *novaNames) nameOf(fipID string) string {
+	if n == nil {
+		return ""
+	}
+	return n.names[fipID]
+}
+
+// resolve looks up the Nova server of every floating ip without a hostname tag.
+// Ports and servers are cached: only ports not seen before are listed, in batches,
+// and servers are listed once per cache period or when new ports showed up.
+func (n *novaNames) resolve(ctx context.Context, client *OpenStackClient, fips []floatingips.FloatingIP, now time.Time) error {
+	if n == nil {
+		return nil
+	}
+	if n.devices == nil || now.Sub(n.fetched) >= n.ttl {
+		n.devices, n.servers, n.fetched = make(map[string]string), nil, now
+	}
+
+	unnamed := make([]floatingips.FloatingIP, 0)
+	attached := make([]floatingips.FloatingIP, 0)
+	missing := make([]string, 0)
+	for _, fip := range fips {
+		if (!n.all && !hasIdentifierTag(fip)) || fip.PortID == "" {
+			continue
+		}
+		name, rejected := recordFromTags(fip.Tags)
+		if name == "" && len(rejected) == 0 {
+			unnamed = append(unnamed, fip)
+		} else if !n.groups {
+			continue
+		}
+		attached = append(attached, fip)
+		if _, ok := n.devices[fip.PortID]; !ok && !slices.Contains(missing, fip.PortID) {
+			missing = append(missing, fip.PortID)
+		}
+	}
+	if len(missing) > 0 {
+		devices, err := client.PortDevices(ctx, missing)
+		if err != nil {
+			return err
+		}
+		for _, id := range missing {
+			n.devices[id] = devices[id]
+		}
+	}
+	if len(unnamed) > 0 && (n.servers == nil || len(missing) > 0) {
+		list, err := client.ListServers(ctx)
+		if err != nil {
+			return err
+		}
+		n.servers = make(map[string]servers.Server, len(list))
+		for _, server := range list {
+			n.servers[server.ID] = server
+		}
+	}
+
+	names := make(map[string]string)
+	for _, fip := range unnamed {
+		server, ok := n.servers[n.devices[fip.PortID]]
+		if !ok {
+			continue
+		}
+		if name := novaName(server, n.domain); name != "" {
+			names[fip.ID] = name
+		} else {
+			log.Debugf("server %s of floating ip %s doesn't carry a valid name", server.ID, fip.ID)
+		}
+	}
+	n.names = names
+
+	// group membership is looked up on every sync so members are picked up right away
+	if n.groups {
+		groups, err := client.ListServerGroups(ctx)
+		if err != nil {
+			return err
+		}
+		n.memberships = memberships(groups, attached, n.devices, n.domain)
+	}
+	return nil
+}
+
+// return the name of a server: its metadata, a hostname tag or the server name itself
+// relative names are placed under the domain, an empty name is returned when it isn't valid
+func novaName(server servers.Server, domain string) string {
+	if name, ok := server.Metadata[NOVA_HOSTNAME_METADATA]; ok {
+		return qualifyName(name, domain)
+	}
+	if server.Tags != nil {
+		for _, tag := range *server.Tags {
+			if strings.HasPrefix(tag, PLUGIN_TAG_IDENTIFIER+":") && !isAttributeTag(tag) && !isTypedTag(tag) {
+				return qualifyName(strings.TrimPrefix(tag, PLUGIN_TAG_IDENTIFIER+":"), domain)
+			}
+		}
+	}
+	return qualifyName(sanitizeLabel(server.Name), domain)
+}
+
+// place a relative name under the domain and validate the result
+func qualifyName(name, domain string) string {
+	name = strings.ToLower(unFqdn(name))
+	if name == "" {
+		return ""
+	}
+	if !strings.Contains(name, ".") {
+		name = name + "." + unFqdn(domain)
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return ""
+	}
+	return name
+}
+
+// turn a free form server name into a dns label
+func sanitizeLabel(name string) string {
+	label := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, name)
+	label = strings.Trim(label, "-")
+	if len(label) > validation.DNS1123LabelMaxLength {
+		label = strings.TrimRight(label[:validation.DNS1123LabelMaxLength], "-")
+	}
+	return label
+}
+
+// return if a floating ip carries the identifier tag
+func hasIdentifierTag(fip floatingips.FloatingIP) bool {
+	return slices.Contains(fip.Tags, PLUGIN_TAG_IDENTIFIER)
+}
@@ -0,0 +1,213 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const novaFips = `
+{
+        "id": "0b9c2f4e-6a1d-4c3b-8e7f-5d2a1c0b9e01",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "198.51.100.21",
+        "fixed_ip_address": "10.0.0.21",
+        "port_id": "port-web",
+        "status": "ACTIVE",
+        "tags": ["coredns:plugin:ospfip"]
+},
+{
+        "id": "0b9c2f4e-6a1d-4c3b-8e7f-5d2a1c0b9e02",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "198.51.100.22",
+        "fixed_ip_address": "10.0.0.22",
+        "port_id": "port-db",
+        "status": "ACTIVE",
+        "tags": ["coredns:plugin:ospfip", "coredns:plugin:ospfip:database.example.net"]
+},
+{
+        "id": "0b9c2f4e-6a1d-4c3b-8e7f-5d2a1c0b9e03",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "198.51.100.23",
+        "fixed_ip_address": "10.0.0.23",
+        "port_id": "port-cache",
+        "status": "ACTIVE",
+        "tags": []
+},
+{
+        "id": "0b9c2f4e-6a1d-4c3b-8e7f-5d2a1c0b9e04",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "198.51.100.24",
+        "status": "DOWN",
+        "tags": []
+}`
+
+const novaPorts = `
+{
+    "ports": [
+        {"id": "port-web", "device_id": "server-web", "device_owner": "compute:nova"},
+        {"id": "port-cache", "device_id": "server-cache", "device_owner": "compute:nova"}
+    ]
+}`
+
+const novaServers = `
+{
+    "servers": [
+        {"id": "server-web", "name": "Web_01", "metadata": {}, "tags": []},
+        {"id": "server-cache", "name": "cache", "metadata": {"ospfip_hostname": "redis"}, "tags": ["coredns:plugin:ospfip:memcache"]}
+    ]
+}`
+
+func TestNovaName(t *testing.T) {
+	tags := func(tags ...string) *[]string { return &tags }
+	cases := []struct {
+		name     string
+		server   servers.Server
+		expected string
+	}{
+		{name: "server name", server: servers.Server{Name: "web1"}, expected: "web1.example.net"},
+		{name: "sanitized server name", server: servers.Server{Name: "Web_01 (prod)"}, expected: "web-01--prod.example.net"},
+		{name: "server tag", server: servers.Server{Name: "web1", Tags: tags("coredns:plugin:ospfip:www")}, expected: "www.example.net"},
+		{name: "absolute server tag", server: servers.Server{Name: "web1", Tags: tags("coredns:plugin:ospfip:www.example.org")}, expected: "www.example.org"},
+		{name: "attribute tag ignored", server: servers.Server{Name: "web1", Tags: tags("coredns:plugin:ospfip:ttl=60")}, expected: "web1.example.net"},
+		{name: "metadata", server: servers.Server{Name: "web1", Metadata: map[string]string{"ospfip_hostname": "api"}, Tags: tags("coredns:plugin:ospfip:www")}, expected: "api.example.net"},
+		{name: "invalid metadata", server: servers.Server{Name: "web1", Metadata: map[string]string{"ospfip_hostname": "not_valid"}}, expected: ""},
+		{name: "unusable server name", server: servers.Server{Name: "___"}, expected: ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := novaName(tt.server, "example.net."); got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsNovaNames(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	var tags []string
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		tags = append(tags, r.URL.Query().Get("tags"))
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(novaFips))
+	})
+	portLists, serverLists := 0, 0
+	th.Mux.HandleFunc("/v2.0/ports", func(w http.ResponseWriter, r *http.Request) {
+		portLists++
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, novaPorts)
+	})
+	th.Mux.HandleFunc("/v2.0/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		serverLists++
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, novaServers)
+	})
+
+	cases := []struct {
+		name     string
+		all      bool
+		tag      string
+		expected map[string]string
+	}{
+		{
+			name: "tagged",
+			tag:  PLUGIN_TAG_IDENTIFIER,
+			expected: map[string]string{
+				"web-01.example.net.":   "198.51.100.21",
+				"database.example.net.": "198.51.100.22",
+			},
+		},
+		{
+			name: "all",
+			all:  true,
+			expected: map[string]string{
+				"web-01.example.net.":   "198.51.100.21",
+				"database.example.net.": "198.51.100.22",
+				"redis.example.net.":    "198.51.100.23",
+			},
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, portLists, serverLists = nil, 0, 0
+			of := New(&OpenStackClient{client: fake.ServiceClient(), compute: fake.ServiceClient()}, 5*time.Minute, 5)
+			of.Origins = []string{"example.net."}
+			nova, err := newNovaNames("example.net", tt.all)
+			if err != nil {
+				t.Fatalf("failed to create nova names: %s", err)
+			}
+			of.nova = nova
+			for i := 0; i < 2; i++ {
+				if err := of.updateRecords(context.TODO()); err != nil {
+					t.Fatalf("failed to update records: %s", err)
+				}
+			}
+			if tags[0] != tt.tag {
+				t.Fatalf("expected floating ips listed with tag %q, got %q", tt.tag, tags[0])
+			}
+			// the second sync is served from the cache
+			if portLists != 1 || serverLists != 1 {
+				t.Fatalf("expected ports and servers listed once, got %d and %d", portLists, serverLists)
+			}
+			got := make(map[string]string)
+			for _, r := range of.records {
+				got[r.Name] = r.IP
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected records %v, got %v", tt.expected, got)
+			}
+			for name, ip := range tt.expected {
+				if got[name] != ip {
+					t.Fatalf("expected %s for %s, got %v", ip, name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestNovaNamesCacheExpiry(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	portLists, serverLists := 0, 0
+	th.Mux.HandleFunc("/v2.0/ports", func(w http.ResponseWriter, r *http.Request) {
+		portLists++
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, novaPorts)
+	})
+	th.Mux.HandleFunc("/v2.0/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		serverLists++
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, novaServers)
+	})
+
+	client := &OpenStackClient{client: fake.ServiceClient(), compute: fake.ServiceClient()}
+	nova, _ := newNovaNames("example.net", false)
+	fips := []floatingips.FloatingIP{{ID: "fip-web", PortID: "port-web", Tags: []string{PLUGIN_TAG_IDENTIFIER}}}
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(time.Minute), now.Add(NOVA_CACHE_TTL + time.Minute)} {
+		if err := nova.resolve(context.TODO(), client, fips, at); err != nil {
+			t.Fatalf("failed to resolve names: %s", err)
+		}
+	}
+	if portLists != 2 || serverLists != 2 {
+		t.Fatalf("expected ports and servers listed twice, got %d and %d", portLists, serverLists)
+	}
+	if got := nova.nameOf("fip-web"); got != "web-01.example.net" {
+		t.Fatalf("expected name 'web-01.example.net', got %q", got)
+	}
+}
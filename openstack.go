@@ -3,16 +3,45 @@ package ospfip
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 
 	"github.com/gophercloud/gophercloud/v2"
 	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
 	"github.com/gophercloud/gophercloud/v2/openstack/config"
 	"github.com/gophercloud/gophercloud/v2/openstack/config/clouds"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/projects"
+	"github.com/gophercloud/gophercloud/v2/openstack/loadbalancer/v2/loadbalancers"
 	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/portforwarding"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/subnetpools"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/subnets"
+	otext "github.com/opentracing/opentracing-go/ext"
 )
 
 type OpenStackClient struct {
-	client *gophercloud.ServiceClient
+	client       *gophercloud.ServiceClient
+	provider     *gophercloud.ProviderClient
+	endpointOpts gophercloud.EndpointOpts
+	identity     *gophercloud.ServiceClient
+	loadbalancer *gophercloud.ServiceClient
+	compute      *gophercloud.ServiceClient
+}
+
+// the compute microversion exposing server tags
+const COMPUTE_MICROVERSION = "2.26"
+
+// the number of ports requested at once, bounded to keep the request url short
+const PORT_BATCH_SIZE = 50
+
+// portIDs lists ports by id, neutron accepts the id filter multiple times
+type portIDs []string
+
+func (ids portIDs) ToPortListQuery() (string, error) {
+	return "?" + url.Values{"id": ids}.Encode(), nil
 }
 
 func NewOpenStackClient() (*OpenStackClient, error) {
@@ -28,22 +57,26 @@ func NewOpenStackClient() (*OpenStackClient, error) {
 	if err != nil {
 		panic(err)
 	}
+	providerClient.HTTPClient.Transport = &tracingTransport{base: providerClient.HTTPClient.Transport}
 
 	client, err := openstack.NewNetworkV2(providerClient, endpointOptions)
 	if err != nil {
 		panic(err)
 	}
-	return &OpenStackClient{client: client}, nil
+	return &OpenStackClient{client: client, provider: providerClient, endpointOpts: endpointOptions}, nil
 }
 
-func (osc *OpenStackClient) ListTaggedFips(tag string) ([]floatingips.FloatingIP, error) {
+func (osc *OpenStackClient) ListTaggedFips(ctx context.Context, tag string) ([]floatingips.FloatingIP, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_FIPS)
+	defer span.Finish()
 
 	listOpts := floatingips.ListOpts{
 		Tags: tag,
 	}
 
-	allPages, err := floatingips.List(osc.client, listOpts).AllPages(context.TODO())
+	allPages, err := floatingips.List(osc.client, listOpts).AllPages(ctx)
 	if err != nil {
+		otext.LogError(span, err)
 		return nil, fmt.Errorf("failed to list floating ips: %s", err)
 	}
 
@@ -51,5 +84,226 @@ func (osc *OpenStackClient) ListTaggedFips(tag string) ([]floatingips.FloatingIP
 	if err != nil {
 		return nil, err
 	}
+	span.SetTag("ospfip.fips", len(allTaggedFIPs))
 	return allTaggedFIPs, nil
 }
+
+// ListTaggedLoadBalancers returns the Octavia load balancers carrying a tag
+func (osc *OpenStackClient) ListTaggedLoadBalancers(ctx context.Context, tag string) ([]loadbalancers.LoadBalancer, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_LOADBALANCERS)
+	defer span.Finish()
+
+	if osc.loadbalancer == nil {
+		if osc.provider == nil {
+			return nil, fmt.Errorf("no load balancer service available")
+		}
+		loadbalancer, err := openstack.NewLoadBalancerV2(osc.provider, osc.endpointOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize load balancer client: %s", err)
+		}
+		osc.loadbalancer = loadbalancer
+	}
+
+	allPages, err := loadbalancers.List(osc.loadbalancer, loadbalancers.ListOpts{Tags: []string{tag}}).AllPages(ctx)
+	if err != nil {
+		otext.LogError(span, err)
+		return nil, fmt.Errorf("failed to list load balancers: %s", err)
+	}
+	lbs, err := loadbalancers.ExtractLoadBalancers(allPages)
+	if err != nil {
+		return nil, err
+	}
+	span.SetTag("ospfip.loadbalancers", len(lbs))
+	return lbs, nil
+}
+
+// PortFloatingIPs returns the floating ips associated with a port
+func (osc *OpenStackClient) PortFloatingIPs(ctx context.Context, portID string) ([]floatingips.FloatingIP, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_FIPS)
+	defer span.Finish()
+	span.SetTag("ospfip.port", portID)
+
+	allPages, err := floatingips.List(osc.client, floatingips.ListOpts{PortID: portID}).AllPages(ctx)
+	if err != nil {
+		otext.LogError(span, err)
+		return nil, fmt.Errorf("failed to list floating ips of port %s: %s", portID, err)
+	}
+	return floatingips.ExtractFloatingIPs(allPages)
+}
+
+// ListPortForwardings returns the port forwardings of a floating ip,
+// none are returned when the port forwarding extension isn't available
+func (osc *OpenStackClient) ListPortForwardings(ctx context.Context, fipID string) ([]portforwarding.PortForwarding, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_PORT_FORWARDINGS)
+	defer span.Finish()
+	span.SetTag("ospfip.fip", fipID)
+
+	allPages, err := portforwarding.List(osc.client, portforwarding.ListOpts{}, fipID).AllPages(ctx)
+	if err != nil {
+		if gophercloud.ResponseCodeIs(err, http.StatusNotFound) {
+			return nil, nil
+		}
+		otext.LogError(span, err)
+		return nil, fmt.Errorf("failed to list port forwardings of floating ip %s: %s", fipID, err)
+	}
+	return portforwarding.ExtractPortForwardings(allPages)
+}
+
+// PortFixedIPs returns the fixed ip addresses of each of the given ports, listed in batches
+func (osc *OpenStackClient) PortFixedIPs(ctx context.Context, ids []string) (map[string][]string, error) {
+	list, err := osc.listPorts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make(map[string][]string, len(list))
+	for _, port := range list {
+		for _, ip := range port.FixedIPs {
+			addresses[port.ID] = append(addresses[port.ID], ip.IPAddress)
+		}
+	}
+	return addresses, nil
+}
+
+// PortDevices returns the device id of each of the given ports, listed in batches
+func (osc *OpenStackClient) PortDevices(ctx context.Context, ids []string) (map[string]string, error) {
+	list, err := osc.listPorts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	devices := make(map[string]string, len(list))
+	for _, port := range list {
+		devices[port.ID] = port.DeviceID
+	}
+	return devices, nil
+}
+
+// list the given ports in batches, ports which no longer exist are left out
+func (osc *OpenStackClient) listPorts(ctx context.Context, ids []string) ([]ports.Port, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_PORTS)
+	defer span.Finish()
+	span.SetTag("ospfip.ports", len(ids))
+
+	list := make([]ports.Port, 0, len(ids))
+	for start := 0; start < len(ids); start += PORT_BATCH_SIZE {
+		end := min(start+PORT_BATCH_SIZE, len(ids))
+		allPages, err := ports.List(osc.client, portIDs(ids[start:end])).AllPages(ctx)
+		if err != nil {
+			otext.LogError(span, err)
+			return nil, fmt.Errorf("failed to list ports: %s", err)
+		}
+		batch, err := ports.ExtractPorts(allPages)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, batch...)
+	}
+	return list, nil
+}
+
+// ListServers returns the Nova servers visible to the project, including their tags
+func (osc *OpenStackClient) ListServers(ctx context.Context) ([]servers.Server, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_SERVERS)
+	defer span.Finish()
+
+	compute, err := osc.computeClient()
+	if err != nil {
+		return nil, err
+	}
+	allPages, err := servers.List(compute, servers.ListOpts{}).AllPages(ctx)
+	if err != nil {
+		otext.LogError(span, err)
+		return nil, fmt.Errorf("failed to list servers: %s", err)
+	}
+	list, err := servers.ExtractServers(allPages)
+	if err != nil {
+		return nil, err
+	}
+	span.SetTag("ospfip.servers", len(list))
+	return list, nil
+}
+
+// ListServerGroups returns the Nova server groups of the project along with their members
+func (osc *OpenStackClient) ListServerGroups(ctx context.Context) ([]servergroups.ServerGroup, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_SERVER_GROUPS)
+	defer span.Finish()
+
+	compute, err := osc.computeClient()
+	if err != nil {
+		return nil, err
+	}
+	allPages, err := servergroups.List(compute, servergroups.ListOpts{}).AllPages(ctx)
+	if err != nil {
+		otext.LogError(span, err)
+		return nil, fmt.Errorf("failed to list server groups: %s", err)
+	}
+	groups, err := servergroups.ExtractServerGroups(allPages)
+	if err != nil {
+		return nil, err
+	}
+	span.SetTag("ospfip.server_groups", len(groups))
+	return groups, nil
+}
+
+// return the compute client, initialized on first use
+func (osc *OpenStackClient) computeClient() (*gophercloud.ServiceClient, error) {
+	if osc.compute == nil {
+		if osc.provider == nil {
+			return nil, fmt.Errorf("no compute service available")
+		}
+		compute, err := openstack.NewComputeV2(osc.provider, osc.endpointOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize compute client: %s", err)
+		}
+		compute.Microversion = COMPUTE_MICROVERSION
+		osc.compute = compute
+	}
+	return osc.compute, nil
+}
+
+// ListSubnets returns the subnets of a network
+func (osc *OpenStackClient) ListSubnets(ctx context.Context, networkID string) ([]subnets.Subnet, error) {
+	span, ctx := childSpan(ctx, SPAN_LIST_SUBNETS)
+	defer span.Finish()
+	span.SetTag("ospfip.network", networkID)
+
+	allPages, err := subnets.List(osc.client, subnets.ListOpts{NetworkID: networkID}).AllPages(ctx)
+	if err != nil {
+		otext.LogError(span, err)
+		return nil, fmt.Errorf("failed to list subnets of network %s: %s", networkID, err)
+	}
+	return subnets.ExtractSubnets(allPages)
+}
+
+// SubnetPoolPrefixes returns the prefixes of a subnet pool
+func (osc *OpenStackClient) SubnetPoolPrefixes(ctx context.Context, id string) ([]string, error) {
+	span, ctx := childSpan(ctx, SPAN_GET_SUBNETPOOL)
+	defer span.Finish()
+	span.SetTag("ospfip.subnetpool", id)
+
+	pool, err := subnetpools.Get(ctx, osc.client, id).Extract()
+	if err != nil {
+		otext.LogError(span, err)
+		return nil, fmt.Errorf("failed to get subnet pool %s: %s", id, err)
+	}
+	return pool.Prefixes, nil
+}
+
+// ProjectName returns the Keystone name of a project
+func (osc *OpenStackClient) ProjectName(ctx context.Context, id string) (string, error) {
+	if osc.identity == nil {
+		if osc.provider == nil {
+			return "", fmt.Errorf("no identity service available")
+		}
+		identity, err := openstack.NewIdentityV3(osc.provider, osc.endpointOpts)
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize identity client: %s", err)
+		}
+		osc.identity = identity
+	}
+
+	project, err := projects.Get(ctx, osc.identity, id).Extract()
+	if err != nil {
+		return "", fmt.Errorf("failed to get project %s: %s", id, err)
+	}
+	return project.Name, nil
+}
@@ -1,6 +1,7 @@
 package ospfip
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
@@ -46,7 +47,7 @@ func TestList(t *testing.T) {
 				fmt.Fprintf(w, tt.response)
 			})
 			osc := &OpenStackClient{client: fake.ServiceClient()}
-			got, err := osc.ListTaggedFips(tt.tag)
+			got, err := osc.ListTaggedFips(context.TODO(), tt.tag)
 			if err != nil {
 				t.Errorf("Failed to list tags: %s", err)
 			}
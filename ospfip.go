@@ -8,27 +8,131 @@ import (
 	"sync"
 	"time"
 
+	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	"github.com/coredns/coredns/plugin/file"
 	"github.com/coredns/coredns/plugin/pkg/dnsutil"
 	"github.com/coredns/coredns/request"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/miekg/dns"
+	ot "github.com/opentracing/opentracing-go"
+	otext "github.com/opentracing/opentracing-go/ext"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 const PLUGIN_TAG_IDENTIFIER = "coredns:plugin:ospfip"
 
+// the maximum number of CNAMEs followed for a single query
+const MAX_CNAME_CHAIN = 8
+
 type OspFip struct {
 	client         *OpenStackClient
 	Origins        []string
 	zones          map[string]*file.Zone
 	zoneNames      []string
 	reverseRecords map[string]string
-	refresh        time.Duration
-	ttl            uint32
-	Next           plugin.Handler
-	mutex          sync.RWMutex
+	// the TTL of the PTR records of addresses whose record has a ttl attribute
+	reverseTTLs map[string]uint32
+	// the configured reverse zones, when empty PTR records are answered for any address
+	reverseZoneNames []string
+	classless        []classlessZone
+	// discover reverse zones from the subnets or subnet pools of the floating networks
+	discover        string
+	discoveredZones []string
+	reverseZones    map[string]*file.Zone
+	refresh         time.Duration
+	ttl             uint32
+	conflictPolicy  string
+	owners          ownershipPolicy
+	Next            plugin.Handler
+	records         []record
+	rejected        []rejection
+	fipErrors       []fipError
+	conflicts       []conflict
+	guard           removalGuard
+	grace           *removalGrace
+	eligibility     eligibility
+	health          *healthChecker
+	weights         map[recordKey]int
+	metadataTXT     bool
+	statusACL       []*net.IPNet
+	wildcardPTR     string
+	fallbackName    string
+	portForwardings bool
+	dualStack       bool
+	loadBalancers   bool
+	nova            *novaNames
+	failover        *failover
+	pending         *syncState
+	pendingReason   error
+	publishMutex    sync.Mutex
+	lastSync        time.Time
+	lastSuccess     time.Time
+	lastSyncErr     error
+	exporter        *exporter
+	auditSinks      []auditSink
+	tracer          ot.Tracer
+	mutex           sync.RWMutex
+}
+
+// record describes a published name and the floating ip it originates from
+type record struct {
+	Name           string        `json:"name"`
+	Zone           string        `json:"-"`
+	Type           string        `json:"type"`
+	IP             string        `json:"ip"`
+	FipID          string        `json:"fip_id"`
+	Tag            string        `json:"tag"`
+	ProjectID      string        `json:"project_id"`
+	Status         string        `json:"status"`
+	FixedIP        string        `json:"fixed_ip"`
+	PortID         string        `json:"port_id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	LastSeen       time.Time     `json:"last_seen,omitempty"`
+	Stale          bool          `json:"stale,omitempty"`
+	TTL            uint32        `json:"ttl,omitempty"`
+	Sinkholed      bool          `json:"sinkholed,omitempty"`
+	Weight         int           `json:"weight,omitempty"`
+	Priority       *int          `json:"priority,omitempty"`
+	Typed          []typedRecord `json:"typed_records,omitempty"`
+	PTR            string        `json:"ptr,omitempty"`
+	LoadBalancerID string        `json:"loadbalancer_id,omitempty"`
+	ServerGroup    string        `json:"server_group,omitempty"`
+}
+
+// fipError describes a floating ip which was skipped because it couldn't be processed
+type fipError struct {
+	FipID string `json:"fip_id"`
+	Name  string `json:"name,omitempty"`
+	// the tag of a typed record which failed, the floating ip itself is still published
+	Tag   string `json:"tag,omitempty"`
+	Error string `json:"error"`
+}
+
+// syncState is the outcome of a sync, published as a whole
+type syncState struct {
+	zones          map[string]*file.Zone
+	zoneNames      []string
+	reverseRecords map[string]string
+	reverseTTLs    map[string]uint32
+	reverseZones   map[string]*file.Zone
+	discovered     []string
+	records        []record
+	rejected       []rejection
+	fipErrors      []fipError
+	conflicts      []conflict
+	// the number of floating ips rejected by the ownership policy, skipped or sinkholed
+	unauthorized int
+	skipped      int
+	sinkholed    int
+}
+
+// rejection describes a floating ip or one of its tags which did not result in a record
+type rejection struct {
+	FipID  string `json:"fip_id"`
+	Tag    string `json:"tag,omitempty"`
+	Reason string `json:"reason"`
 }
 
 type zone struct {
@@ -38,17 +142,24 @@ type zone struct {
 
 func New(client *OpenStackClient, refresh time.Duration, ttl uint32) *OspFip {
 	return &OspFip{
-		client:  client,
-		refresh: refresh,
-		ttl:     ttl,
+		client:         client,
+		refresh:        refresh,
+		ttl:            ttl,
+		conflictPolicy: CONFLICT_ROUNDROBIN,
+		guard:          removalGuard{maxRemovalPercent: 100},
+		failover:       newFailover(),
 	}
 }
 
 func (of *OspFip) Run(ctx context.Context) error {
 	log.Info("Running initial update of records...")
-	if err := of.updateRecords(); err != nil {
+	if err := of.updateRecords(ctx); err != nil {
 		return err
 	}
+	if of.health != nil {
+		of.health.onProbed = of.updateFailover
+		go of.health.run(ctx)
+	}
 
 	go func() {
 		timer := time.NewTimer(of.refresh)
@@ -60,7 +171,7 @@ func (of *OspFip) Run(ctx context.Context) error {
 				log.Debugf("stop updating records for %v: %v", of.zoneNames, ctx.Err())
 				return
 			case <-timer.C:
-				if err := of.updateRecords(); err != nil && ctx.Err() == nil {
+				if err := of.updateRecords(ctx); err != nil && ctx.Err() == nil {
 					log.Errorf("Failed to update zones %v: %v", of.zoneNames, err)
 				}
 			}
@@ -71,13 +182,37 @@ func (of *OspFip) Run(ctx context.Context) error {
 
 func (of *OspFip) Name() string { return PLUGIN_NAME }
 
+// SetTracer sets the tracer used for spans of the background sync.
+func (of *OspFip) SetTracer(tracer ot.Tracer) {
+	of.mutex.Lock()
+	of.tracer = tracer
+	of.mutex.Unlock()
+}
+
 func (of *OspFip) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg) (int, error) {
 	state := request.Request{W: w, Req: r}
 	qname := state.Name()
+	if isStatusQuery(state) {
+		return of.serveStatus(w, r, state)
+	}
+	if reverseZoneNames := of.servedReverseZones(); len(reverseZoneNames) > 0 {
+		if alias, rName := classlessAlias(of.classless, qname, of.ttl); alias != nil && state.QType() == dns.TypePTR {
+			return of.serveReverse(ctx, w, r, state, rName, alias)
+		}
+		if rName := plugin.Zones(reverseZoneNames).Matches(qname); rName != "" {
+			return of.serveReverse(ctx, w, r, state, rName, nil)
+		}
+		if state.QType() == dns.TypePTR {
+			return plugin.NextOrFailure(of.Name(), of.Next, ctx, w, r)
+		}
+	}
 
+	span, _ := childSpan(ctx, SPAN_ZONE_MATCH)
 	of.mutex.Lock()
 	zName := plugin.Zones(of.zoneNames).Matches(qname)
 	of.mutex.Unlock()
+	span.SetTag("ospfip.zone", zName)
+	span.Finish()
 	if zName == "" && state.QType() != dns.TypePTR {
 		return plugin.NextOrFailure(of.Name(), of.Next, ctx, w, r)
 	}
@@ -95,27 +230,43 @@ func (of *OspFip) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 
 	switch state.QType() {
 	case dns.TypePTR:
+		span, _ := childSpan(ctx, SPAN_PTR)
 		addr := dnsutil.ExtractAddressFromReverse(qname)
 		if addr == "" {
+			span.Finish()
 			return plugin.NextOrFailure(of.Name(), of.Next, ctx, w, r)
 		}
 		of.mutex.RLock()
 		record := of.reverseRecords[addr]
+		ttl, ok := of.reverseTTLs[addr]
 		of.mutex.RUnlock()
+		if !ok {
+			ttl = of.ttl
+		}
+		span.SetTag("ospfip.record", record)
+		span.Finish()
 		if record == "" {
 			return plugin.NextOrFailure(of.Name(), of.Next, ctx, w, r)
 		}
-		rfc1035 := fmt.Sprintf("%s %d IN %s %s", qname, of.ttl, "PTR", dns.Fqdn(record))
+		rfc1035 := fmt.Sprintf("%s %d IN %s %s", qname, ttl, "PTR", dns.Fqdn(record))
 
 		rr, err := dns.NewRR(rfc1035)
 		if err != nil {
 			return dns.RcodeServerFailure, fmt.Errorf("failed to parse resource record: %v", err)
 		}
 		m.Answer = []dns.RR{rr}
-	case dns.TypeA, dns.TypeAAAA:
+	case dns.TypeA, dns.TypeAAAA, dns.TypeCNAME, dns.TypeTXT, dns.TypeSRV, dns.TypeMX, dns.TypeCAA:
+		span, _ := childSpan(ctx, SPAN_LOOKUP)
+		of.mutex.RLock()
+		m.Answer, m.Ns, m.Extra = of.lookup(ctx, state, z, qname)
+		of.mutex.RUnlock()
+		m.Answer = of.failover.filter(m.Answer)
+		m.Answer = of.health.filter(m.Answer)
 		of.mutex.RLock()
-		m.Answer, m.Ns, m.Extra, _ = z.Lookup(ctx, state, qname)
+		m.Answer = weightedShuffle(m.Answer, of.weights)
 		of.mutex.RUnlock()
+		span.SetTag("ospfip.answers", len(m.Answer))
+		span.Finish()
 	}
 
 	if len(m.Answer) == 0 {
@@ -126,71 +277,439 @@ func (of *OspFip) ServeDNS(ctx context.Context, w dns.ResponseWriter, r *dns.Msg
 	return dns.RcodeSuccess, nil
 }
 
-func (of *OspFip) updateRecords() error {
-	taggedFips, err := of.client.ListTaggedFips(PLUGIN_TAG_IDENTIFIER)
+// lookup a name in a zone, following CNAMEs into the other zones of the plugin
+// CNAMEs pointing outside of the zones are left for the resolver to follow
+func (of *OspFip) lookup(ctx context.Context, state request.Request, z *file.Zone, qname string) ([]dns.RR, []dns.RR, []dns.RR) {
+	// keep the zone from resolving CNAME targets through the server itself
+	ctx = context.WithValue(ctx, dnsserver.Key{}, nil)
+	var answer, ns, extra []dns.RR
+	for i := 0; i < MAX_CNAME_CHAIN; i++ {
+		rrs, authority, additional, _ := z.Lookup(ctx, state, qname)
+		answer = append(answer, rrs...)
+		ns, extra = authority, additional
+		if len(rrs) == 0 || state.QType() == dns.TypeCNAME {
+			break
+		}
+		cname, ok := rrs[len(rrs)-1].(*dns.CNAME)
+		if !ok {
+			break
+		}
+		zName := plugin.Zones(of.zoneNames).Matches(cname.Target)
+		if zName == "" {
+			break
+		}
+		z, qname = of.zones[zName], cname.Target
+	}
+	return answer, ns, extra
+}
+
+func (of *OspFip) updateRecords(ctx context.Context) (err error) {
+	defer func() { of.setSyncResult(time.Now(), err) }()
+
+	of.mutex.RLock()
+	tracer := of.tracer
+	of.mutex.RUnlock()
+	span, ctx := rootSpan(ctx, tracer, SPAN_SYNC)
+	defer span.Finish()
+
+	taggedFips, err := of.client.ListTaggedFips(ctx, of.nova.listTag())
 	if err != nil {
+		otext.LogError(span, err)
 		return err
 	}
-	zones := make(map[string]*file.Zone)
-	zoneNames := make([]string, 0)
-	reverseRecords := make(map[string]string)
+	// keep the previously resolved names when Nova can't be reached
+	if err := of.nova.resolve(ctx, of.client, taggedFips, time.Now()); err != nil {
+		log.Warningf("failed to resolve names from Nova: %v", err)
+	}
+	records := make([]record, 0, len(taggedFips))
+	rejected := make([]rejection, 0)
+	fipErrors := make([]fipError, 0)
+	validationFailures := 0
 
+	// every floating ip is processed in isolation, a malformed one doesn't prevent publishing the others
 	for _, fip := range taggedFips {
-		ip := net.ParseIP(fip.FloatingIP)
-		if ip == nil {
-			log.Errorf("failed to parse IP '%s': %s.\n", fip.FloatingIP, err)
+		// without the identifier tag a floating ip is only published under its Nova name
+		if of.nova.skip(fip) {
 			continue
 		}
-
-		recordTag := recordFromTags(fip.Tags)
-		recordName := plugin.Name(string(recordTag)).Normalize()
-		if plugin.Zones(of.Origins).Matches(recordName) == "" {
-			log.Debugf("'%s' does not match the configured origin(s), skipping...", recordName)
+		r, rejectedTags, err := of.recordFromFip(fip)
+		rejected = append(rejected, rejectedTags...)
+		if err != nil {
+			log.Debugf("skipping floating ip %s: %v", fip.ID, err)
+			fipErrors = append(fipErrors, fipError{FipID: fip.ID, Error: err.Error()})
+			validationFailures++
 			continue
 		}
-		zoneName := zoneFromRecord(recordName)
+		if r == nil {
+			continue
+		}
+		records = append(records, *r)
+	}
+	if of.loadBalancers {
+		// keep the previous load balancer records when Octavia can't be reached
+		if lbRecords, lbRejected, lbErrors, err := of.loadBalancerRecords(ctx, taggedFips); err != nil {
+			otext.LogError(span, err)
+			log.Warningf("failed to list load balancers, keeping the previous records: %v", err)
+			records = append(records, of.previousLoadBalancerRecords()...)
+		} else {
+			records = append(records, lbRecords...)
+			rejected = append(rejected, lbRejected...)
+			fipErrors = append(fipErrors, lbErrors...)
+			validationFailures += len(lbErrors)
+		}
+	}
+	records = append(records, of.serverGroupRecords(taggedFips)...)
 
-		rfc1035 := fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(recordName), of.ttl, aType(ip), ip)
-		rr, err := dns.NewRR(rfc1035)
+	records, unauthorized := of.owners.filter(ctx, records, of.client.ProjectName)
+	rejected = append(rejected, unauthorized...)
+	records, skipped, sinkholed := of.eligibility.apply(records)
+	rejected = append(rejected, skipped...)
+	records = of.addPortForwardings(ctx, records)
+	records = of.addDualStack(ctx, records)
+	records, conflicts := resolveConflicts(records, of.conflictPolicy)
+	records = of.grace.apply(records, taggedFips, of.ttl, time.Now())
+	records, clashes := withoutCNAMEClashes(records)
+	rejected = append(rejected, clashes...)
+
+	zones, zoneNames, reverseRecords, reverseTTLs, buildErrors := buildZones(records, of.ttl)
+	fipErrors = append(fipErrors, buildErrors...)
+	if len(buildErrors) > 0 {
+		records = withoutFailed(records, buildErrors)
+	}
+	of.mutex.RLock()
+	discovered := of.discoveredZones
+	of.mutex.RUnlock()
+	if of.discover != "" {
+		// keep the previously discovered zones when discovery fails
+		if zones, err := of.discoverReverseZones(ctx, taggedFips); err != nil {
+			log.Warningf("failed to discover reverse zones: %v", err)
+		} else {
+			discovered = zones
+		}
+	}
+	var reverseZones map[string]*file.Zone
+	if reverseZoneNames := append(append([]string{}, of.reverseZoneNames...), discovered...); len(reverseZoneNames) > 0 {
+		if reverseZones, err = buildConfiguredReverseZones(reverseZoneNames, of.classless, reverseRecords, reverseTTLs, of.ttl); err != nil {
+			otext.LogError(span, err)
+			return err
+		}
+	}
+	if len(fipErrors) > 0 {
+		log.Warningf("skipped %d floating ip(s) during sync: %s", len(fipErrors), summarizeErrors(fipErrors))
+	}
+
+	span.SetTag("ospfip.fips", len(taggedFips))
+	span.SetTag("ospfip.zones", len(zoneNames))
+	span.SetTag("ospfip.reverse_records", len(reverseRecords))
+	span.SetTag("ospfip.validation_failures", validationFailures)
+	span.SetTag("ospfip.errors", len(fipErrors))
+	span.SetTag("ospfip.conflicts", len(conflicts))
+	span.SetTag("ospfip.unauthorized", len(unauthorized))
+	span.SetTag("ospfip.skipped", len(skipped))
+	span.SetTag("ospfip.sinkholed", sinkholed)
+	fipErrorTotal.Add(float64(len(fipErrors)))
+
+	state := &syncState{
+		zones:          zones,
+		zoneNames:      zoneNames,
+		reverseRecords: reverseRecords,
+		reverseTTLs:    reverseTTLs,
+		reverseZones:   reverseZones,
+		discovered:     discovered,
+		records:        records,
+		rejected:       rejected,
+		fipErrors:      fipErrors,
+		conflicts:      conflicts,
+		unauthorized:   len(unauthorized),
+		skipped:        len(skipped),
+		sinkholed:      sinkholed,
+	}
+
+	of.mutex.RLock()
+	previous, synced := of.records, of.zones != nil
+	of.mutex.RUnlock()
+	if synced {
+		if err := of.guard.check(previous, records); err != nil {
+			otext.LogError(span, err)
+			of.hold(state, err)
+			return err
+		}
+	}
+	of.publish(state)
+	return nil
+}
+
+// publish makes the outcome of a sync the served state
+func (of *OspFip) publish(state *syncState) {
+	of.publishMutex.Lock()
+	defer of.publishMutex.Unlock()
+	of.publishLocked(state)
+}
+
+// publishLocked publishes a sync, the caller holds the publish mutex
+func (of *OspFip) publishLocked(state *syncState) {
+	of.mutex.Lock()
+	previous, synced := of.records, of.zones != nil
+	of.zones = state.zones
+	of.zoneNames = state.zoneNames
+	of.reverseRecords = state.reverseRecords
+	of.reverseTTLs = state.reverseTTLs
+	of.reverseZones = state.reverseZones
+	of.discoveredZones = state.discovered
+	of.records = state.records
+	of.rejected = state.rejected
+	of.fipErrors = state.fipErrors
+	of.conflicts = state.conflicts
+	of.weights = weightsFromRecords(state.records)
+	of.pending = nil
+	of.pendingReason = nil
+	of.mutex.Unlock()
+	heldSync.Set(0)
+	reportSync(len(state.records), len(state.fipErrors), len(state.conflicts), state.unauthorized)
+	reportIneligible(state.skipped, state.sinkholed)
+	of.health.update(state.records)
+	of.updateFailover()
+
+	// the initial sync has nothing to compare with
+	if synced && len(of.auditSinks) > 0 {
+		of.audit(diffRecords(previous, state.records, time.Now()))
+	}
+	log.Debugf("currently authoritative for zones %s", state.zoneNames)
+
+	if of.exporter != nil {
+		if err := of.exportZones(state); err != nil {
+			log.Errorf("%v", err)
+		}
+	}
+}
+
+// build the candidate record for a floating ip
+// returns a nil record when the floating ip doesn't carry a name within the configured origins
+func (of *OspFip) recordFromFip(fip floatingips.FloatingIP) (*record, []rejection, error) {
+	ip := net.ParseIP(fip.FloatingIP)
+	if ip == nil {
+		return nil, nil, fmt.Errorf("invalid floating ip address %q", fip.FloatingIP)
+	}
+
+	recordTag, rejected := recordFromTags(fip.Tags)
+	for i := range rejected {
+		rejected[i].FipID = fip.ID
+	}
+	tag := PLUGIN_TAG_IDENTIFIER + ":" + recordTag
+	// only floating ips without any hostname tag are named after their server or fall back to a generated name
+	if recordTag == "" && len(rejected) == 0 {
+		if name := of.nova.nameOf(fip.ID); name != "" {
+			recordTag, tag = name, PLUGIN_TAG_IDENTIFIER
+		} else if of.fallbackName != "" {
+			recordTag, tag = expandTemplate(of.fallbackName, ip), PLUGIN_TAG_IDENTIFIER
+		}
+	}
+	if recordTag == "" {
+		return nil, rejected, fmt.Errorf("no valid record tag found")
+	}
+	attrs, rejectedAttrs := attributesFromTags(fip.Tags)
+	for _, r := range rejectedAttrs {
+		r.FipID = fip.ID
+		rejected = append(rejected, r)
+	}
+	recordName := plugin.Name(string(recordTag)).Normalize()
+	if plugin.Zones(of.Origins).Matches(recordName) == "" {
+		log.Debugf("'%s' does not match the configured origin(s), skipping...", recordName)
+		rejected = append(rejected, rejection{FipID: fip.ID, Tag: tag, Reason: "does not match the configured origin(s)"})
+		return nil, rejected, nil
+	}
+	typed, rejectedTyped := typedRecordsFromTags(fip.Tags, dns.Fqdn(recordName), of.Origins)
+	for _, r := range rejectedTyped {
+		r.FipID = fip.ID
+		rejected = append(rejected, r)
+	}
+	if of.metadataTXT {
+		if t, ok := metadataRecord(dns.Fqdn(recordName), fip); ok {
+			typed = append(typed, t)
+		}
+	}
+	ptr := ""
+	if strings.HasPrefix(recordName, "*.") && of.wildcardPTR != "" {
+		ptr = wildcardPTRTarget(dns.Fqdn(recordName), of.wildcardPTR, ip)
+	}
+
+	return &record{
+		Name:      dns.Fqdn(recordName),
+		Zone:      zoneFromRecord(recordName),
+		Type:      aType(ip),
+		IP:        ip.String(),
+		FipID:     fip.ID,
+		Tag:       tag,
+		ProjectID: projectOf(fip),
+		Status:    fip.Status,
+		FixedIP:   fip.FixedIP,
+		PortID:    fip.PortID,
+		CreatedAt: fip.CreatedAt,
+		TTL:       attrs.ttl,
+		Weight:    attrs.weight,
+		Priority:  attrs.priority,
+		Typed:     typed,
+		PTR:       ptr,
+	}, rejected, nil
+}
+
+// build the zones and reverse records for a set of records
+// a record which fails to insert is skipped and reported without affecting the others
+func buildZones(records []record, ttl uint32) (map[string]*file.Zone, []string, map[string]string, map[string]uint32, []fipError) {
+	zones := make(map[string]*file.Zone)
+	zoneNames := make([]string, 0)
+	reverseRecords := make(map[string]string)
+	reverseTTLs := make(map[string]uint32)
+	errs := make([]fipError, 0)
+
+	for _, r := range records {
+		rrTTL := ttl
+		if r.TTL > 0 {
+			rrTTL = r.TTL
+		}
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", r.Name, rrTTL, r.Type, r.IP))
 		if err != nil {
-			return fmt.Errorf("failed to parse resource record: %v", err)
+			errs = append(errs, fipError{FipID: r.FipID, Name: r.Name, Error: fmt.Sprintf("failed to parse resource record: %v", err)})
+			continue
 		}
 
-		zone, ok := zones[zoneName]
+		zone, ok := zones[r.Zone]
 		if !ok || zone == nil {
-			zone = file.NewZone(zoneName, "")
-			zoneNames = append(zoneNames, zoneName)
-
-			soa := soaFromOrigin(zoneName, of.ttl)
-			err = zone.Insert(soa[0])
+			zone = file.NewZone(r.Zone, "")
+			if err := zone.Insert(soaFromOrigin(r.Zone, ttl)[0]); err != nil {
+				errs = append(errs, fipError{FipID: r.FipID, Name: r.Name, Error: fmt.Sprintf("failed to insert record: %v", err)})
+				continue
+			}
+			zones[r.Zone] = zone
+			zoneNames = append(zoneNames, r.Zone)
+		}
+		if err := zone.Insert(rr); err != nil {
+			errs = append(errs, fipError{FipID: r.FipID, Name: r.Name, Error: fmt.Sprintf("failed to insert record: %v", err)})
+			continue
+		}
+		for _, t := range r.Typed {
+			trr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", t.Name, rrTTL, t.Type, t.Data))
+			if err == nil {
+				err = zone.Insert(trr)
+			}
 			if err != nil {
-				return fmt.Errorf("failed to insert record: %v", err)
+				log.Warningf("skipping %s record '%s' of floating ip %s: %v", t.Type, t.Name, r.FipID, err)
+				errs = append(errs, fipError{FipID: r.FipID, Name: t.Name, Tag: t.Tag, Error: fmt.Sprintf("failed to insert %s record: %v", t.Type, err)})
 			}
 		}
 
-		zone.Insert(rr)
-		if err != nil {
-			return fmt.Errorf("failed to insert record: %v", err)
+		if r.Sinkholed {
+			continue
 		}
-		zones[zoneName] = zone
-		if err := validation.IsWildcardDNS1123Subdomain(unFqdn(recordName)); err != nil {
-			log.Debugf("Adding PTR record for '%s' as '%s'", ip.String(), recordName)
-			reverseRecords[ip.String()] = dns.Fqdn(recordName)
+		// a server group name doesn't replace the PTR record of a member
+		if _, ok := reverseRecords[r.IP]; ok && r.ServerGroup != "" {
+			continue
+		}
+		if r.PTR != "" {
+			log.Debugf("Adding PTR record for '%s' as '%s'", r.IP, r.PTR)
+			reverseRecords[r.IP] = r.PTR
+		} else if err := validation.IsWildcardDNS1123Subdomain(unFqdn(r.Name)); err != nil {
+			log.Debugf("Adding PTR record for '%s' as '%s'", r.IP, r.Name)
+			reverseRecords[r.IP] = r.Name
+		} else {
+			continue
 		}
+		if r.TTL > 0 {
+			reverseTTLs[r.IP] = r.TTL
+		} else {
+			delete(reverseTTLs, r.IP)
+		}
+	}
+	return zones, zoneNames, reverseRecords, reverseTTLs, errs
+}
+
+// drop the records which failed to build, a failing typed record doesn't drop its floating ip
+func withoutFailed(records []record, errs []fipError) []record {
+	failed := make(map[string]struct{}, len(errs))
+	for _, e := range errs {
+		if e.Tag == "" {
+			failed[e.FipID] = struct{}{}
+		}
+	}
+	kept := make([]record, 0, len(records))
+	for _, r := range records {
+		if _, ok := failed[r.FipID]; !ok {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// render the errors of a sync for logging
+func summarizeErrors(errs []fipError) string {
+	parts := make([]string, 0, len(errs))
+	for _, e := range errs {
+		parts = append(parts, fmt.Sprintf("%s: %s", e.FipID, e.Error))
 	}
+	return strings.Join(parts, "; ")
+}
+
+// select the served priority of names with a priority from the published records and their health
+func (of *OspFip) updateFailover() {
+	of.mutex.RLock()
+	records := of.records
+	of.mutex.RUnlock()
+	of.failover.update(records, of.health.healthy)
+}
+
+// hand change events to the configured audit sinks
+func (of *OspFip) audit(events []changeEvent) {
+	if len(events) == 0 {
+		return
+	}
+	for _, e := range events {
+		log.Infof("record %s: %s %s %s -> %s (floating ip %s)", e.Action, e.Name, e.Type, e.OldIP, e.NewIP, e.FipID)
+	}
+	for _, sink := range of.auditSinks {
+		if err := sink.Send(events); err != nil {
+			log.Errorf("failed to send change events: %v", err)
+		}
+	}
+}
+
+// write the forward and reverse zones to the export directory
+// the configured and discovered reverse zones are exported as is, otherwise they are derived from the PTR records
+func (of *OspFip) exportZones(state *syncState) error {
+	reverseZones := state.reverseZones
+	if reverseZones == nil {
+		var err error
+		if reverseZones, err = reverseZonesFromRecords(state.reverseRecords, state.reverseTTLs, of.ttl); err != nil {
+			return fmt.Errorf("failed to export zones: %v", err)
+		}
+	}
+	all := make(map[string]*file.Zone, len(state.zones)+len(reverseZones))
+	for name, z := range state.zones {
+		all[name] = z
+	}
+	for name, z := range reverseZones {
+		all[name] = z
+	}
+	return of.exporter.Export(all)
+}
+
+// keep track of the outcome of the last sync
+func (of *OspFip) setSyncResult(at time.Time, err error) {
 	of.mutex.Lock()
-	of.zones = zones
-	of.zoneNames = zoneNames
-	of.reverseRecords = reverseRecords
-	of.mutex.Unlock()
-	log.Debugf("currently authoritative for zones %s", of.zoneNames)
-	return nil
+	defer of.mutex.Unlock()
+	of.lastSync = at
+	of.lastSyncErr = err
+	if err == nil {
+		of.lastSuccess = at
+		syncCount.WithLabelValues("success").Inc()
+	} else {
+		syncCount.WithLabelValues("failure").Inc()
+	}
 }
 
 // craft an soa to make sure Lookup works: https://github.com/coredns/coredns/blob/8868454177bdd3e70e71bd52d3c0e38bcf0d77fd/plugin/file/lookup.go#L44-L46
 func soaFromOrigin(origin string, ttl uint32) []dns.RR {
 	hdr := dns.RR_Header{Name: origin, Ttl: ttl, Class: dns.ClassINET, Rrtype: dns.TypeSOA}
-	return []dns.RR{&dns.SOA{Hdr: hdr, Ns: "localhost.", Mbox: "root.localhost.", Serial: 1, Refresh: 0, Retry: 0, Expire: 0, Minttl: ttl}}
+	return []dns.RR{&dns.SOA{Hdr: hdr, Ns: NAMESERVER, Mbox: "root.localhost.", Serial: 1, Refresh: 0, Retry: 0, Expire: 0, Minttl: ttl}}
 }
 
 // return the zone part of a given record
@@ -201,10 +720,12 @@ func zoneFromRecord(in string) string {
 
 // extract a record from a list of tags
 // a record only resolvaes to a single floating ip so we expect a 1:1 tag-to-zone mapping
-func recordFromTags(tags []string) string {
+// tags carrying the identifier which do not hold a valid domain are returned as rejected
+func recordFromTags(tags []string) (string, []rejection) {
+	rejected := make([]rejection, 0)
 	for _, tag := range tags {
-		// skip the identified tag
-		if tag == PLUGIN_TAG_IDENTIFIER {
+		// skip the identified tag, attribute tags and typed record tags
+		if tag == PLUGIN_TAG_IDENTIFIER || isAttributeTag(tag) || isTypedTag(tag) {
 			continue
 		}
 		log.Debugf("processing tag '%s'\n", tag)
@@ -213,16 +734,19 @@ func recordFromTags(tags []string) string {
 		log.Debugf("validating if '%s' is a domain name", domain)
 		if err := validation.IsFullyQualifiedDomainName(field.NewPath(""), domain); err == nil {
 			// stop processing after we found a domain
-			return domain
+			return domain, rejected
 		} else if err := validation.IsWildcardDNS1123Subdomain(domain); err == nil {
 			// stop processing after we found a domain
-			return domain
+			return domain, rejected
 		} else {
 			log.Debugf("'%s' is not a valid zone\n", domain)
+			if strings.HasPrefix(tag, PLUGIN_TAG_IDENTIFIER+":") {
+				rejected = append(rejected, rejection{Tag: tag, Reason: fmt.Sprintf("'%s' is not a valid domain name", domain)})
+			}
 			continue
 		}
 	}
-	return ""
+	return "", rejected
 }
 
 // IsWildcardDNS1123Subdomain doesn't consider fqdn domains so unfqdn before validating
@@ -235,6 +759,14 @@ func unFqdn(record string) string {
 	return record
 }
 
+// return the project owning a floating ip, older deployments only report the tenant
+func projectOf(fip floatingips.FloatingIP) string {
+	if fip.ProjectID != "" {
+		return fip.ProjectID
+	}
+	return fip.TenantID
+}
+
 // return the dns type for a given IP v4 or v6
 func aType(addr net.IP) string {
 	if addr.To4() != nil {
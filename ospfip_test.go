@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -57,7 +58,7 @@ func TestRecordFromTags(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := recordFromTags(tt.tags)
+			got, _ := recordFromTags(tt.tags)
 			if !reflect.DeepEqual(got, tt.expected) {
 				t.Errorf("got %s, want %s", got, tt.expected)
 			}
@@ -226,7 +227,7 @@ func TestUpdateRecords(t *testing.T) {
 			of := New(osc, refresh, 5)
 			of.Origins = []string{"."}
 
-			err := of.updateRecords()
+			err := of.updateRecords(context.TODO())
 			if err != nil {
 				t.Errorf("failed to update records: %s", err)
 			}
@@ -246,6 +247,54 @@ func TestUpdateRecords(t *testing.T) {
 	}
 }
 
+func TestUpdateRecordsPartialFailure(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(malformedFip, taggedFip))
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.Origins = []string{"."}
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("expected malformed floating ip not to fail the sync, got %s", err)
+	}
+	zone, ok := of.zones["mycluster.example.net."]
+	if !ok || zone.Len() != 1 {
+		t.Fatalf("expected valid record to be published, got %+v", of.zones)
+	}
+	if len(of.fipErrors) != 1 || of.fipErrors[0].FipID != "0b7c1c4e-5d9a-4b6f-8e3a-2f1d6c9e7b55" {
+		t.Fatalf("expected the malformed floating ip to be reported, got %+v", of.fipErrors)
+	}
+}
+
+func TestBuildZones(t *testing.T) {
+	records := []record{
+		{Name: "a.example.org.", Zone: "example.org.", Type: "A", IP: "192.0.2.1", FipID: "fip-1"},
+		{Name: strings.Repeat("x", 64) + ".example.org.", Zone: "example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-2"},
+		{Name: "*.apps.example.org.", Zone: "apps.example.org.", Type: "A", IP: "192.0.2.3", FipID: "fip-3"},
+	}
+	zones, zoneNames, reverseRecords, _, errs := buildZones(records, 60)
+	if len(zoneNames) != 2 || len(zones) != 2 {
+		t.Fatalf("expected 2 zones, got %v", zoneNames)
+	}
+	if zones["example.org."].Len() != 1 {
+		t.Fatalf("expected 1 record in example.org., got %d", zones["example.org."].Len())
+	}
+	if len(errs) != 1 || errs[0].FipID != "fip-2" {
+		t.Fatalf("expected an error for fip-2, got %+v", errs)
+	}
+	if len(reverseRecords) != 1 || reverseRecords["192.0.2.1"] != "a.example.org." {
+		t.Fatalf("expected a single reverse record for 192.0.2.1, got %+v", reverseRecords)
+	}
+	if got := withoutFailed(records, errs); len(got) != 2 {
+		t.Fatalf("expected 2 records after dropping failed ones, got %+v", got)
+	}
+}
+
 func TestZoneFromRecord(t *testing.T) {
 	cases := []struct {
 		Name     string
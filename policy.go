@@ -0,0 +1,152 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/miekg/dns"
+)
+
+// namePattern is either a zone, matching the name itself and all names below it,
+// or a glob containing a '*' (e.g. 'api-*.example.net.')
+type namePattern string
+
+func newNamePattern(pattern string) (namePattern, error) {
+	pattern = plugin.Name(pattern).Normalize()
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return namePattern(pattern), nil
+}
+
+func (p namePattern) matches(name string) bool {
+	if strings.Contains(string(p), "*") {
+		ok, _ := path.Match(string(p), name)
+		return ok
+	}
+	return dns.IsSubDomain(string(p), name)
+}
+
+// return the index of the most specific pattern matching a name, -1 if none matches
+func mostSpecific(patterns []namePattern, name string) int {
+	best := -1
+	for i, p := range patterns {
+		if p.matches(name) && (best < 0 || len(p) > len(patterns[best])) {
+			best = i
+		}
+	}
+	return best
+}
+
+// ownerRule restricts the names matching a pattern to a set of projects
+type ownerRule struct {
+	pattern  namePattern
+	projects []string
+}
+
+// ownershipPolicy decides which projects may claim a name, names not matched by any rule may be claimed by any project
+type ownershipPolicy []ownerRule
+
+// projectNameFunc resolves a project id to its Keystone name
+type projectNameFunc func(ctx context.Context, id string) (string, error)
+
+func newOwnerRule(pattern string, projects []string) (ownerRule, error) {
+	p, err := newNamePattern(pattern)
+	if err != nil {
+		return ownerRule{}, err
+	}
+	if len(projects) == 0 {
+		return ownerRule{}, fmt.Errorf("no projects given for %q", p)
+	}
+	return ownerRule{pattern: p, projects: projects}, nil
+}
+
+// return the most specific rule matching a name
+func (p ownershipPolicy) match(name string) (ownerRule, bool) {
+	patterns := make([]namePattern, 0, len(p))
+	for _, r := range p {
+		patterns = append(patterns, r.pattern)
+	}
+	best := mostSpecific(patterns, name)
+	if best < 0 {
+		return ownerRule{}, false
+	}
+	return p[best], true
+}
+
+// authorize returns an error if the project is not allowed to claim the name
+// projects in a rule are matched by id and, when no id matches, by their Keystone name
+func (p ownershipPolicy) authorize(ctx context.Context, name, projectID string, projectName projectNameFunc) error {
+	rule, ok := p.match(name)
+	if !ok {
+		return nil
+	}
+	for _, allowed := range rule.projects {
+		if allowed == projectID {
+			return nil
+		}
+	}
+	if projectName != nil && projectID != "" {
+		pName, err := projectName(ctx, projectID)
+		if err != nil {
+			log.Debugf("failed to resolve name of project %s: %v", projectID, err)
+		}
+		for _, allowed := range rule.projects {
+			if pName != "" && allowed == pName {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("project %q is not authorized for '%s' (owned by %v via '%s')", projectID, name, rule.projects, rule.pattern)
+}
+
+// filter the records claimed by unauthorized projects, returning them as rejections
+// the typed records of a floating ip are authorized by their own name, so they can't claim names the project doesn't own
+func (p ownershipPolicy) filter(ctx context.Context, records []record, projectName projectNameFunc) ([]record, []rejection) {
+	if len(p) == 0 {
+		return records, nil
+	}
+	names := cachedProjectNames(projectName)
+	kept := make([]record, 0, len(records))
+	rejected := make([]rejection, 0)
+	for _, r := range records {
+		if err := p.authorize(ctx, r.Name, r.ProjectID, names); err != nil {
+			log.Warningf("rejecting floating ip %s: %v", r.FipID, err)
+			rejected = append(rejected, rejection{FipID: r.FipID, Tag: r.Tag, Reason: err.Error()})
+			continue
+		}
+		typed := make([]typedRecord, 0, len(r.Typed))
+		for _, t := range r.Typed {
+			if err := p.authorize(ctx, t.Name, r.ProjectID, names); err != nil {
+				log.Warningf("rejecting %s record of floating ip %s: %v", t.Type, r.FipID, err)
+				rejected = append(rejected, rejection{FipID: r.FipID, Tag: t.Tag, Reason: err.Error()})
+				continue
+			}
+			typed = append(typed, t)
+		}
+		if len(r.Typed) > 0 {
+			r.Typed = typed
+		}
+		kept = append(kept, r)
+	}
+	return kept, rejected
+}
+
+// wrap a projectNameFunc so every project is only looked up once
+func cachedProjectNames(projectName projectNameFunc) projectNameFunc {
+	if projectName == nil {
+		return nil
+	}
+	cache := make(map[string]string)
+	return func(ctx context.Context, id string) (string, error) {
+		if name, ok := cache[id]; ok {
+			return name, nil
+		}
+		name, err := projectName(ctx, id)
+		cache[id] = name
+		return name, err
+	}
+}
@@ -0,0 +1,152 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/miekg/dns"
+)
+
+func TestOwnershipPolicyAuthorize(t *testing.T) {
+	rules := []struct {
+		pattern  string
+		projects []string
+	}{
+		{pattern: "example.net", projects: []string{"shared"}},
+		{pattern: "prod.example.net", projects: []string{"prod-id"}},
+		{pattern: "api-*.dev.example.net", projects: []string{"dev"}},
+	}
+	policy := make(ownershipPolicy, 0)
+	for _, r := range rules {
+		rule, err := newOwnerRule(r.pattern, r.projects)
+		if err != nil {
+			t.Fatalf("failed to create rule: %s", err)
+		}
+		policy = append(policy, rule)
+	}
+	projectNames := func(ctx context.Context, id string) (string, error) {
+		names := map[string]string{"dev-id": "dev"}
+		if name, ok := names[id]; ok {
+			return name, nil
+		}
+		return "", fmt.Errorf("project %s not found", id)
+	}
+
+	cases := []struct {
+		name      string
+		record    string
+		project   string
+		authorize bool
+	}{
+		{name: "name outside any rule", record: "api.example.org.", project: "any", authorize: true},
+		{name: "zone rule allows project", record: "www.example.net.", project: "shared", authorize: true},
+		{name: "zone rule rejects project", record: "www.example.net.", project: "other", authorize: false},
+		{name: "most specific zone wins", record: "api.prod.example.net.", project: "shared", authorize: false},
+		{name: "most specific zone allows project", record: "api.prod.example.net.", project: "prod-id", authorize: true},
+		{name: "wildcard record within zone", record: "*.prod.example.net.", project: "prod-id", authorize: true},
+		{name: "glob allows project by name", record: "api-1.dev.example.net.", project: "dev-id", authorize: true},
+		{name: "glob rejects project", record: "api-1.dev.example.net.", project: "shared", authorize: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.authorize(context.TODO(), tt.record, tt.project, projectNames)
+			if tt.authorize && err != nil {
+				t.Fatalf("expected %s to be authorized for %s, got %s", tt.project, tt.record, err)
+			}
+			if !tt.authorize && err == nil {
+				t.Fatalf("expected %s not to be authorized for %s", tt.project, tt.record)
+			}
+		})
+	}
+}
+
+func TestOwnershipPolicyFilter(t *testing.T) {
+	rule, _ := newOwnerRule("prod.example.net", []string{"prod-id"})
+	policy := ownershipPolicy{rule}
+	records := []record{
+		{Name: "api.prod.example.net.", FipID: "fip-1", ProjectID: "prod-id"},
+		{Name: "api.prod.example.net.", FipID: "fip-2", ProjectID: "intruder"},
+	}
+	lookups := 0
+	projectNames := func(ctx context.Context, id string) (string, error) {
+		lookups++
+		return "", nil
+	}
+	kept, rejected := policy.filter(context.TODO(), append(records, records[1]), projectNames)
+	if len(kept) != 1 || kept[0].FipID != "fip-1" {
+		t.Fatalf("expected only fip-1 to be kept, got %+v", kept)
+	}
+	if len(rejected) != 2 || rejected[0].FipID != "fip-2" {
+		t.Fatalf("expected fip-2 to be rejected, got %+v", rejected)
+	}
+	if lookups != 1 {
+		t.Fatalf("expected project names to be cached, got %d lookups", lookups)
+	}
+}
+
+const intruderFip = `
+{
+        "id": "9d3e2c1b-0a4f-4b5e-8c7d-6e5f4a3b2c01",
+        "tenant_id": "intruder",
+        "floating_ip_address": "198.51.100.66",
+        "status": "ACTIVE",
+        "tags": [
+          "coredns:plugin:ospfip",
+          "coredns:plugin:ospfip:foo.example.net",
+          "coredns:plugin:ospfip:rr:TXT:_acme-challenge.api.prod.example.net.:\"token\""
+        ]
+}`
+
+func TestUpdateRecordsTypedOwnership(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(intruderFip))
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.Origins = []string{"example.net."}
+	of.Next = test.NextHandler(dns.RcodeNameError, nil)
+	rule, _ := newOwnerRule("prod.example.net", []string{"prodproject"})
+	of.owners = ownershipPolicy{rule}
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+
+	// the floating ip keeps its own name, only the typed record claiming a protected name is rejected
+	if len(of.records) != 1 || of.records[0].Name != "foo.example.net." || len(of.records[0].Typed) != 0 {
+		t.Fatalf("expected foo.example.net. without typed records, got %+v", of.records)
+	}
+	if len(of.rejected) != 1 || of.rejected[0].Tag != "coredns:plugin:ospfip:rr:TXT:_acme-challenge.api.prod.example.net.:\"token\"" {
+		t.Fatalf("expected the typed record tag to be rejected, got %+v", of.rejected)
+	}
+
+	w := dnstest.NewRecorder(&test.ResponseWriter{})
+	r := new(dns.Msg)
+	r.SetQuestion("_acme-challenge.api.prod.example.net.", dns.TypeTXT)
+	if _, err := of.ServeDNS(context.TODO(), w, r); err != nil {
+		t.Fatal(err)
+	}
+	if w.Msg != nil && len(w.Msg.Answer) > 0 {
+		t.Fatalf("expected no answer for the protected name, got %v", w.Msg.Answer)
+	}
+}
+
+func TestNewOwnerRule(t *testing.T) {
+	if _, err := newOwnerRule("[.example.net", []string{"p"}); err == nil {
+		t.Fatalf("expected invalid pattern to fail")
+	}
+	if _, err := newOwnerRule("example.net", nil); err == nil {
+		t.Fatalf("expected rule without projects to fail")
+	}
+}
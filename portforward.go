@@ -0,0 +1,71 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/portforwarding"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// a service name as defined by RFC 6335, with an optional leading underscore
+var serviceNamePattern = regexp.MustCompile(`^_?([a-zA-Z0-9]+-)*[a-zA-Z0-9]+$`)
+
+// return the service name carried by the description of a port forwarding, if any
+func serviceName(pf portforwarding.PortForwarding) string {
+	name := strings.TrimSpace(pf.Description)
+	if !serviceNamePattern.MatchString(name) {
+		return ""
+	}
+	name = strings.TrimPrefix(name, "_")
+	if len(name) > 15 || !strings.ContainsAny(strings.ToLower(name), "abcdefghijklmnopqrstuvwxyz") {
+		return ""
+	}
+	return strings.ToLower(name)
+}
+
+// srvFromPortForwardings returns the SRV records of the port forwardings of a floating ip which name a service,
+// the address record of the floating ip is added to the additional section as target of the SRV records
+func srvFromPortForwardings(r record, forwardings []portforwarding.PortForwarding) []typedRecord {
+	// a wildcard can't be the target of an SRV record
+	if validation.IsWildcardDNS1123Subdomain(unFqdn(r.Name)) == nil {
+		return nil
+	}
+	typed := make([]typedRecord, 0)
+	for _, pf := range forwardings {
+		service := serviceName(pf)
+		if service == "" || pf.Protocol == "" {
+			continue
+		}
+		typed = append(typed, typedRecord{
+			Name: fmt.Sprintf("_%s._%s.%s", service, strings.ToLower(pf.Protocol), r.Name),
+			Type: "SRV",
+			Data: fmt.Sprintf("0 0 %d %s", pf.ExternalPort, r.Name),
+			Tag:  fmt.Sprintf("port-forwarding:%s", pf.ID),
+		})
+	}
+	return typed
+}
+
+// add the SRV records of the port forwardings to the records of floating ips without an associated port
+// failing to fetch the port forwardings of a floating ip doesn't affect its address record
+func (of *OspFip) addPortForwardings(ctx context.Context, records []record) []record {
+	if !of.portForwardings {
+		return records
+	}
+	for i, r := range records {
+		// sinkholed records don't serve the floating ip, load balancers have no port forwardings
+		if r.PortID != "" || r.Sinkholed || r.LoadBalancerID != "" {
+			continue
+		}
+		forwardings, err := of.client.ListPortForwardings(ctx, r.FipID)
+		if err != nil {
+			log.Warningf("%v", err)
+			continue
+		}
+		records[i].Typed = append(records[i].Typed, srvFromPortForwardings(r, forwardings)...)
+	}
+	return records
+}
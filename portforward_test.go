@@ -0,0 +1,147 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/portforwarding"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/miekg/dns"
+)
+
+const portForwardingsResponse = `
+{
+    "port_forwardings": [
+        {"id": "pf-1", "description": "https", "protocol": "tcp", "external_port": 8443, "internal_port": 443},
+        {"id": "pf-2", "description": "ssh access for the ops team", "protocol": "tcp", "external_port": 2222, "internal_port": 22},
+        {"id": "pf-3", "description": "_sip", "protocol": "udp", "external_port": 5060, "internal_port": 5060}
+    ]
+}`
+
+func TestServiceName(t *testing.T) {
+	cases := []struct {
+		description string
+		expected    string
+	}{
+		{description: "https", expected: "https"},
+		{description: "_LDAP", expected: "ldap"},
+		{description: "kerberos-adm", expected: "kerberos-adm"},
+		{description: "web server", expected: ""},
+		{description: "-https", expected: ""},
+		{description: "8080", expected: ""},
+		{description: "a-very-long-service-name", expected: ""},
+		{description: "", expected: ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := serviceName(portforwarding.PortForwarding{Description: tt.description}); got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsPortForwardings(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(taggedFip, taggedWildcardFip))
+	})
+	lists := 0
+	th.Mux.HandleFunc("/v2.0/floatingips/49426401-21ef-4314-a5ca-05423f4405ad/port_forwardings", func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, portForwardingsResponse)
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.Origins = []string{"."}
+	of.Next = test.NextHandler(dns.RcodeNameError, nil)
+	// port forwardings are only listed when enabled
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+	if lists != 0 {
+		t.Fatalf("expected no port forwardings to be listed by default, got %d request(s)", lists)
+	}
+	of.portForwardings = true
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+
+	cases := []struct {
+		qname string
+		port  uint16
+	}{
+		{qname: "_https._tcp.api.mycluster.example.net.", port: 8443},
+		{qname: "_sip._udp.api.mycluster.example.net.", port: 5060},
+	}
+	for _, tt := range cases {
+		t.Run(tt.qname, func(t *testing.T) {
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			r := new(dns.Msg)
+			r.SetQuestion(tt.qname, dns.TypeSRV)
+			if _, err := of.ServeDNS(context.TODO(), w, r); err != nil {
+				t.Fatal(err)
+			}
+			if w.Msg == nil || len(w.Msg.Answer) != 1 {
+				t.Fatalf("expected a single SRV record, got %v", w.Msg)
+			}
+			srv := w.Msg.Answer[0].(*dns.SRV)
+			if srv.Port != tt.port || srv.Target != "api.mycluster.example.net." {
+				t.Fatalf("expected SRV to api.mycluster.example.net.:%d, got %s", tt.port, srv)
+			}
+			if len(w.Msg.Extra) != 1 || w.Msg.Extra[0].(*dns.A).A.String() != "192.0.0.3" {
+				t.Fatalf("expected the A record of the floating ip as additional record, got %v", w.Msg.Extra)
+			}
+		})
+	}
+
+	for _, r := range of.records {
+		if r.Name == "*.mycluster.example.net." && len(r.Typed) != 0 {
+			t.Fatalf("expected no SRV records for a wildcard, got %+v", r.Typed)
+		}
+		if r.Name == "api.mycluster.example.net." && len(r.Typed) != 2 {
+			t.Fatalf("expected SRV records of the port forwardings naming a service, got %+v", r.Typed)
+		}
+	}
+}
+
+func TestAddPortForwardingsSkipped(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	lists := 0
+	th.Mux.HandleFunc("/v2.0/floatingips/", func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, portForwardingsResponse)
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.portForwardings = true
+	records := of.addPortForwardings(context.TODO(), []record{
+		{Name: "sinkholed.example.org.", Type: "A", IP: "192.0.2.99", FipID: "fip-1", Sinkholed: true},
+		{Name: "lb.example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-2", LoadBalancerID: "lb-1"},
+		{Name: "attached.example.org.", Type: "A", IP: "192.0.2.3", FipID: "fip-3", PortID: "port-1"},
+	})
+	if lists != 0 {
+		t.Fatalf("expected no port forwardings to be listed, got %d request(s)", lists)
+	}
+	for _, r := range records {
+		if len(r.Typed) != 0 {
+			t.Fatalf("expected no SRV records, got %+v", r)
+		}
+	}
+}
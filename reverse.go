@@ -0,0 +1,139 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/coredns/coredns/plugin/file"
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+// the nameserver of the zones of the plugin, used for their SOA and NS records
+const NAMESERVER = "localhost."
+
+// return the reverse zones for a list of zone names or networks
+func reverseZonesFromArgs(args []string) ([]string, error) {
+	zones := make([]string, 0, len(args))
+	for _, arg := range args {
+		names := plugin.Host(arg).NormalizeExact()
+		if len(names) == 0 {
+			return nil, fmt.Errorf("invalid reverse zone %q", arg)
+		}
+		for _, name := range names {
+			if !dns.IsSubDomain("in-addr.arpa.", name) && !dns.IsSubDomain("ip6.arpa.", name) {
+				return nil, fmt.Errorf("%q is not a reverse zone", arg)
+			}
+			zones = append(zones, name)
+		}
+	}
+	return zones, nil
+}
+
+// craft the NS record of a zone
+func nsFromOrigin(origin string, ttl uint32) dns.RR {
+	return &dns.NS{Hdr: dns.RR_Header{Name: origin, Ttl: ttl, Class: dns.ClassINET, Rrtype: dns.TypeNS}, Ns: NAMESERVER}
+}
+
+// create an empty zone with SOA and NS records
+func newReverseZone(name string, ttl uint32) *file.Zone {
+	zone := file.NewZone(name, "")
+	zone.Insert(soaFromOrigin(name, ttl)[0])
+	zone.Insert(nsFromOrigin(name, ttl))
+	return zone
+}
+
+// build the reverse zones holding the PTR records of the given addresses,
+// locate returns the name of the PTR record and its zone, or an empty zone when it isn't published
+// ttls holds the TTL of the PTR records which don't use the default one
+func buildReverseZones(reverseRecords map[string]string, ttls map[string]uint32, ttl uint32, locate func(reverse string, ip net.IP) (string, string)) (map[string]*file.Zone, error) {
+	zones := make(map[string]*file.Zone)
+	for addr, name := range reverseRecords {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			continue
+		}
+		reverse, err := dns.ReverseAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		owner, zoneName := locate(reverse, ip)
+		if zoneName == "" {
+			log.Debugf("not publishing PTR record for '%s', it is outside the reverse zones", addr)
+			continue
+		}
+
+		zone, ok := zones[zoneName]
+		if !ok {
+			zone = newReverseZone(zoneName, ttl)
+			zones[zoneName] = zone
+		}
+		rrTTL := ttl
+		if t, ok := ttls[addr]; ok {
+			rrTTL = t
+		}
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN PTR %s", owner, rrTTL, dns.Fqdn(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resource record: %v", err)
+		}
+		zone.Insert(rr)
+	}
+	return zones, nil
+}
+
+// build the configured reverse zones, including the ones without any PTR record
+// addresses within a classless zone are published there rather than in a classful zone
+func buildConfiguredReverseZones(names []string, classless []classlessZone, reverseRecords map[string]string, ttls map[string]uint32, ttl uint32) (map[string]*file.Zone, error) {
+	zones, err := buildReverseZones(reverseRecords, ttls, ttl, func(reverse string, ip net.IP) (string, string) {
+		if c, ok := classlessZoneFor(classless, ip); ok {
+			return c.owner(ip), c.name
+		}
+		return reverse, plugin.Zones(names).Matches(reverse)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if _, ok := zones[name]; !ok {
+			zones[name] = newReverseZone(name, ttl)
+		}
+	}
+	return zones, nil
+}
+
+// lookup a name within a reverse zone and answer authoritatively, including NXDOMAIN
+// an alias pointing into the zone is prepended to the answer, which isn't authoritative then
+func (of *OspFip) serveReverse(ctx context.Context, w dns.ResponseWriter, r *dns.Msg, state request.Request, zName string, alias *dns.CNAME) (int, error) {
+	span, _ := childSpan(ctx, SPAN_PTR)
+	defer span.Finish()
+	of.mutex.RLock()
+	z, ok := of.reverseZones[zName]
+	of.mutex.RUnlock()
+	if !ok || z == nil {
+		// not synced yet
+		return dns.RcodeServerFailure, nil
+	}
+
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	qname := state.Name()
+	if alias != nil {
+		qname = alias.Target
+	}
+	var result file.Result
+	m.Answer, m.Ns, m.Extra, result = z.Lookup(ctx, state, qname)
+	if result == file.NameError {
+		m.Rcode = dns.RcodeNameError
+	}
+	if alias != nil {
+		m.Answer = append([]dns.RR{alias}, m.Answer...)
+		m.Authoritative = false
+	}
+	span.SetTag("ospfip.zone", zName)
+	span.SetTag("ospfip.answers", len(m.Answer))
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
@@ -0,0 +1,118 @@
+package ospfip
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+)
+
+func TestReverseZonesFromArgs(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		expected []string
+		valid    bool
+	}{
+		{name: "zone name", args: []string{"100.51.198.in-addr.arpa"}, expected: []string{"100.51.198.in-addr.arpa."}, valid: true},
+		{name: "ipv4 network", args: []string{"198.51.100.0/24"}, expected: []string{"100.51.198.in-addr.arpa."}, valid: true},
+		{name: "ipv4 network off an octet boundary", args: []string{"198.51.100.0/23"}, expected: []string{"100.51.198.in-addr.arpa.", "101.51.198.in-addr.arpa."}, valid: true},
+		{name: "ipv6 network", args: []string{"2001:db8::/32"}, expected: []string{"8.b.d.0.1.0.0.2.ip6.arpa."}, valid: true},
+		{name: "forward zone", args: []string{"example.net"}, valid: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reverseZonesFromArgs(tt.args)
+			if !tt.valid {
+				if err == nil {
+					t.Fatalf("expected %v to be invalid", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected %v to be valid, got %s", tt.args, err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServeDNSReverseZones(t *testing.T) {
+	reverseRecords := map[string]string{
+		"198.51.100.1": "api.example.net.",
+		"192.0.2.1":    "other.example.net.",
+	}
+	names := []string{"100.51.198.in-addr.arpa.", "8.b.d.0.1.0.0.2.ip6.arpa."}
+	reverseZones, err := buildConfiguredReverseZones(names, nil, reverseRecords, nil, 60)
+	if err != nil {
+		t.Fatalf("failed to build reverse zones: %s", err)
+	}
+	if len(reverseZones) != 2 {
+		t.Fatalf("expected the configured zones only, got %v", reverseZones)
+	}
+
+	of := OspFip{
+		reverseZoneNames: names,
+		reverseZones:     reverseZones,
+		reverseRecords:   reverseRecords,
+		Next:             test.NextHandler(dns.RcodeNameError, nil),
+	}
+	cases := []struct {
+		name   string
+		qname  string
+		qtype  uint16
+		rcode  int
+		answer string
+		next   bool
+	}{
+		{name: "ptr within zone", qname: "1.100.51.198.in-addr.arpa.", qtype: dns.TypePTR, rcode: dns.RcodeSuccess, answer: "api.example.net."},
+		{name: "unknown name within zone", qname: "2.100.51.198.in-addr.arpa.", qtype: dns.TypePTR, rcode: dns.RcodeNameError},
+		{name: "empty ipv6 zone", qname: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", qtype: dns.TypePTR, rcode: dns.RcodeNameError},
+		{name: "soa of zone", qname: "100.51.198.in-addr.arpa.", qtype: dns.TypeSOA, rcode: dns.RcodeSuccess, answer: "SOA"},
+		{name: "ns of zone", qname: "100.51.198.in-addr.arpa.", qtype: dns.TypeNS, rcode: dns.RcodeSuccess, answer: NAMESERVER},
+		{name: "ptr outside of zones is passed on", qname: "1.2.0.192.in-addr.arpa.", qtype: dns.TypePTR, next: true},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			r := new(dns.Msg)
+			r.SetQuestion(tt.qname, tt.qtype)
+			rc, err := of.ServeDNS(context.TODO(), w, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.next {
+				if rc != dns.RcodeNameError || w.Msg != nil {
+					t.Fatalf("expected query to be passed on, got %d %v", rc, w.Msg)
+				}
+				return
+			}
+			if w.Msg.Rcode != tt.rcode || !w.Msg.Authoritative {
+				t.Fatalf("expected authoritative rcode %d, got %v", tt.rcode, w.Msg)
+			}
+			switch tt.answer {
+			case "":
+				if len(w.Msg.Answer) != 0 || len(w.Msg.Ns) != 1 || w.Msg.Ns[0].Header().Rrtype != dns.TypeSOA {
+					t.Fatalf("expected no answer and the SOA as authority, got %v", w.Msg)
+				}
+			case "SOA":
+				if len(w.Msg.Answer) != 1 || w.Msg.Answer[0].Header().Rrtype != dns.TypeSOA {
+					t.Fatalf("expected SOA, got %v", w.Msg.Answer)
+				}
+			case NAMESERVER:
+				if len(w.Msg.Answer) != 1 || w.Msg.Answer[0].(*dns.NS).Ns != NAMESERVER {
+					t.Fatalf("expected NS, got %v", w.Msg.Answer)
+				}
+			default:
+				if len(w.Msg.Answer) != 1 || w.Msg.Answer[0].(*dns.PTR).Ptr != tt.answer {
+					t.Fatalf("expected PTR to %s, got %v", tt.answer, w.Msg.Answer)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package ospfip
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+)
+
+// membership ties a floating ip to the server group of its server
+type membership struct {
+	group string
+	name  string
+}
+
+// return the round robin name of a server group named like 'coredns:plugin:ospfip:web'
+// server groups carry neither tags nor metadata, an empty name is returned for other groups
+func groupName(group servergroups.ServerGroup, domain string) string {
+	if strings.HasPrefix(group.Name, PLUGIN_TAG_IDENTIFIER+":") {
+		return qualifyName(strings.TrimPrefix(group.Name, PLUGIN_TAG_IDENTIFIER+":"), domain)
+	}
+	return ""
+}
+
+// return the membership of every floating ip whose port is attached to a member of a named server group
+func memberships(groups []servergroups.ServerGroup, fips []floatingips.FloatingIP, devices map[string]string, domain string) map[string]membership {
+	members := make(map[string]membership)
+	for _, group := range groups {
+		name := groupName(group, domain)
+		if name == "" {
+			continue
+		}
+		for _, server := range group.Members {
+			members[server] = membership{group: group.ID, name: name}
+		}
+	}
+	found := make(map[string]membership)
+	for _, fip := range fips {
+		if device := devices[fip.PortID]; device != "" {
+			if m, ok := members[device]; ok {
+				found[fip.ID] = m
+			}
+		}
+	}
+	return found
+}
+
+// membershipOf returns the server group membership of a floating ip found during the last sync
+func (n *novaNames) membershipOf(fipID string) (membership, bool) {
+	if n == nil {
+		return membership{}, false
+	}
+	m, ok := n.memberships[fipID]
+	return m, ok
+}
+
+// serverGroupRecords returns the round robin records of the floating ips of server group members,
+// published along with the records of the floating ips themselves and sharing their attributes
+func (of *OspFip) serverGroupRecords(fips []floatingips.FloatingIP) []record {
+	records := make([]record, 0)
+	for _, fip := range fips {
+		m, ok := of.nova.membershipOf(fip.ID)
+		if !ok {
+			continue
+		}
+		tags := []string{PLUGIN_TAG_IDENTIFIER, PLUGIN_TAG_IDENTIFIER + ":" + m.name}
+		for _, tag := range fip.Tags {
+			if isAttributeTag(tag) {
+				tags = append(tags, tag)
+			}
+		}
+		fip.Tags = tags
+		r, _, err := of.recordFromFip(fip)
+		if err != nil || r == nil {
+			continue
+		}
+		r.ServerGroup = m.group
+		r.Typed = nil
+		records = append(records, *r)
+	}
+	return records
+}
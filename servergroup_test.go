@@ -0,0 +1,150 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servergroups"
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+)
+
+const groupFips = `
+{
+        "id": "5e1f0c2a-8b7d-4e3c-9a6f-1d2b3c4e5f01",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "198.51.100.31",
+        "fixed_ip_address": "10.0.0.31",
+        "port_id": "port-web-1",
+        "status": "ACTIVE",
+        "created_at": "2024-01-01T00:00:00Z",
+        "tags": ["coredns:plugin:ospfip", "coredns:plugin:ospfip:weight=3"]
+},
+{
+        "id": "5e1f0c2a-8b7d-4e3c-9a6f-1d2b3c4e5f02",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "198.51.100.32",
+        "fixed_ip_address": "10.0.0.32",
+        "port_id": "port-web-2",
+        "status": "ACTIVE",
+        "created_at": "2024-02-01T00:00:00Z",
+        "tags": ["coredns:plugin:ospfip", "coredns:plugin:ospfip:web-b.example.net"]
+}`
+
+const groupPorts = `
+{
+    "ports": [
+        {"id": "port-web-1", "device_id": "server-web-1", "device_owner": "compute:nova"},
+        {"id": "port-web-2", "device_id": "server-web-2", "device_owner": "compute:nova"}
+    ]
+}`
+
+const groupServers = `
+{
+    "servers": [
+        {"id": "server-web-1", "name": "web-a", "metadata": {}, "tags": []},
+        {"id": "server-web-2", "name": "web-b", "metadata": {}, "tags": []}
+    ]
+}`
+
+func TestGroupName(t *testing.T) {
+	cases := []struct {
+		name     string
+		group    servergroups.ServerGroup
+		expected string
+	}{
+		{name: "plain name", group: servergroups.ServerGroup{Name: "web"}, expected: ""},
+		{name: "tagged name", group: servergroups.ServerGroup{Name: "coredns:plugin:ospfip:web"}, expected: "web.example.net"},
+		{name: "absolute tagged name", group: servergroups.ServerGroup{Name: "coredns:plugin:ospfip:web.example.org"}, expected: "web.example.org"},
+		{name: "invalid name", group: servergroups.ServerGroup{Name: "coredns:plugin:ospfip:not_valid"}, expected: ""},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupName(tt.group, "example.net."); got != tt.expected {
+				t.Fatalf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsServerGroups(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(groupFips))
+	})
+	th.Mux.HandleFunc("/v2.0/ports", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, groupPorts)
+	})
+	th.Mux.HandleFunc("/v2.0/servers/detail", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, groupServers)
+	})
+	members := `"server-web-1", "server-web-2"`
+	th.Mux.HandleFunc("/v2.0/os-server-groups", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"server_groups": [{"id": "group-web", "name": "coredns:plugin:ospfip:web", "members": [%s]}]}`, members)
+	})
+
+	of := New(&OpenStackClient{client: fake.ServiceClient(), compute: fake.ServiceClient()}, 5*time.Minute, 5)
+	of.Origins = []string{"example.net."}
+	of.conflictPolicy = CONFLICT_OLDEST
+	nova, err := newNovaNames("example.net", false)
+	if err != nil {
+		t.Fatalf("failed to create nova names: %s", err)
+	}
+	nova.groups = true
+	of.nova = nova
+
+	groupIPs := func() []string {
+		ips := make([]string, 0)
+		for _, r := range of.records {
+			if r.Name == "web.example.net." {
+				ips = append(ips, r.IP)
+			}
+		}
+		sort.Strings(ips)
+		return ips
+	}
+
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+	// the members share the group name regardless of the conflict policy
+	if got := groupIPs(); len(got) != 2 || got[0] != "198.51.100.31" || got[1] != "198.51.100.32" {
+		t.Fatalf("expected both members under 'web.example.net.', got %v", got)
+	}
+	if len(of.conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got %+v", of.conflicts)
+	}
+	if of.weights[recordKey{name: "web.example.net.", rrType: "A", ip: "198.51.100.31"}] != 3 {
+		t.Fatalf("expected the group record to share the weight of its floating ip, got %v", of.weights)
+	}
+	// the members keep their own PTR records
+	if got := of.reverseRecords["198.51.100.31"]; got != "web-a.example.net." {
+		t.Fatalf("expected PTR 'web-a.example.net.' for 198.51.100.31, got %q", got)
+	}
+	if got := of.reverseRecords["198.51.100.32"]; got != "web-b.example.net." {
+		t.Fatalf("expected PTR 'web-b.example.net.' for 198.51.100.32, got %q", got)
+	}
+
+	// a member leaving the group is dropped on the next sync
+	members = `"server-web-2"`
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+	if got := groupIPs(); len(got) != 1 || got[0] != "198.51.100.32" {
+		t.Fatalf("expected only 198.51.100.32 under 'web.example.net.', got %v", got)
+	}
+}
@@ -3,13 +3,18 @@ package ospfip
 import (
 	"context"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/coredns/caddy"
 	"github.com/coredns/coredns/core/dnsserver"
 	"github.com/coredns/coredns/plugin"
 	clog "github.com/coredns/coredns/plugin/pkg/log"
+	"github.com/coredns/coredns/plugin/pkg/trace"
 )
 
 var log = clog.NewWithPlugin("ospfip")
@@ -26,6 +31,29 @@ func setup(c *caddy.Controller) error {
 	for c.Next() {
 		var ttl uint32 = DEFAULT_TTL
 		refresh := DEFAULT_REFRESH * time.Minute
+		debugListen := ""
+		exportDir := ""
+		auditSinks := make([]auditSink, 0)
+		conflictPolicy := CONFLICT_ROUNDROBIN
+		owners := make(ownershipPolicy, 0)
+		guard := removalGuard{maxRemovalPercent: 100}
+		var grace *removalGrace
+		var elig eligibility
+		healthChecks := make([]healthCheck, 0)
+		healthInterval := DEFAULT_HEALTH_INTERVAL
+		healthTimeout := DEFAULT_HEALTH_TIMEOUT
+		metadataTXT := false
+		statusACL := make([]*net.IPNet, 0)
+		wildcardPTR := ""
+		fallbackName := ""
+		reverseZoneNames := make([]string, 0)
+		classless := make([]classlessZone, 0)
+		discover := ""
+		portForwardings := false
+		dualStack := false
+		loadBalancers := false
+		var nova *novaNames
+		serverGroups := false
 
 		args := c.RemainingArgs()
 
@@ -60,10 +88,287 @@ func setup(c *caddy.Controller) error {
 				} else {
 					return plugin.Error(PLUGIN_NAME, c.ArgErr())
 				}
+			case "debug_listen":
+				if c.NextArg() {
+					debugListen = c.Val()
+					if _, _, err := net.SplitHostPort(debugListen); err != nil {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse debug_listen address: %v", err))
+					}
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "export_dir":
+				if c.NextArg() {
+					exportDir = c.Val()
+					fi, err := os.Stat(exportDir)
+					if err != nil {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to use export_dir: %v", err))
+					}
+					if !fi.IsDir() {
+						return plugin.Error(PLUGIN_NAME, c.Errf("export_dir is not a directory: %q", exportDir))
+					}
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "audit_file":
+				if c.NextArg() {
+					auditSinks = append(auditSinks, newFileSink(c.Val()))
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "audit_webhook":
+				if c.NextArg() {
+					u, err := url.Parse(c.Val())
+					if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse audit_webhook url: %q", c.Val()))
+					}
+					auditSinks = append(auditSinks, newWebhookSink(u.String()))
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "conflict":
+				if c.NextArg() {
+					conflictPolicy = c.Val()
+					if !validConflictPolicy(conflictPolicy) {
+						return plugin.Error(PLUGIN_NAME, c.Errf("unknown conflict policy %q, expected one of %v", conflictPolicy, conflictPolicies))
+					}
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "owner":
+				ownerArgs := c.RemainingArgs()
+				if len(ownerArgs) < 2 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				rule, err := newOwnerRule(ownerArgs[0], ownerArgs[1:])
+				if err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse owner: %v", err))
+				}
+				owners = append(owners, rule)
+			case "max_removal_percent":
+				if c.NextArg() {
+					percent, err := strconv.Atoi(c.Val())
+					if err != nil {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse max_removal_percent: %v", err))
+					}
+					if percent < 0 || percent > 100 {
+						return plugin.Error(PLUGIN_NAME, c.Errf("max_removal_percent must be between 0 and 100: %d", percent))
+					}
+					guard.maxRemovalPercent = percent
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "min_records":
+				if c.NextArg() {
+					minRecords, err := strconv.Atoi(c.Val())
+					if err != nil {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse min_records: %v", err))
+					}
+					if minRecords < 0 {
+						return plugin.Error(PLUGIN_NAME, c.Errf("min_records must not be negative: %d", minRecords))
+					}
+					guard.minRecords = minRecords
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "removal_grace":
+				graceArgs := c.RemainingArgs()
+				if len(graceArgs) < 1 || len(graceArgs) > 2 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				period, err := time.ParseDuration(graceArgs[0])
+				if err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse removal_grace duration: %v", err))
+				}
+				if period <= 0 {
+					return plugin.Error(PLUGIN_NAME, c.Errf("removal_grace must be greater than 0: %q", graceArgs[0]))
+				}
+				grace = &removalGrace{period: period}
+				if len(graceArgs) == 2 {
+					graceTTL, err := strconv.Atoi(graceArgs[1])
+					if err != nil {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse removal_grace ttl: %v", err))
+					}
+					if graceTTL < 0 {
+						return plugin.Error(PLUGIN_NAME, c.Errf("removal_grace ttl must not be negative: %d", graceTTL))
+					}
+					grace.ttl = uint32(graceTTL)
+				}
+			case "require_status":
+				if c.NextArg() {
+					elig.status = strings.ToUpper(c.Val())
+				} else {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "require_association":
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				elig.association = true
+			case "sinkhole":
+				sinkholeArgs := c.RemainingArgs()
+				if len(sinkholeArgs) < 1 || len(sinkholeArgs) > 2 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				for _, arg := range sinkholeArgs {
+					addr := net.ParseIP(arg)
+					if addr == nil {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse sinkhole address: %q", arg))
+					}
+					if addr.To4() != nil {
+						elig.sinkholeV4 = addr
+					} else {
+						elig.sinkholeV6 = addr
+					}
+				}
+			case "health_check":
+				checkArgs := c.RemainingArgs()
+				if len(checkArgs) < 3 || len(checkArgs) > 4 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				path := ""
+				if len(checkArgs) == 4 {
+					path = checkArgs[3]
+				}
+				check, err := newHealthCheck(checkArgs[0], checkArgs[1], checkArgs[2], path)
+				if err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse health_check: %v", err))
+				}
+				healthChecks = append(healthChecks, check)
+			case "health_interval", "health_timeout":
+				directive := c.Val()
+				if !c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				d, err := time.ParseDuration(c.Val())
+				if err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse %s: %v", directive, err))
+				}
+				if d <= 0 {
+					return plugin.Error(PLUGIN_NAME, c.Errf("%s must be greater than 0: %q", directive, c.Val()))
+				}
+				if directive == "health_interval" {
+					healthInterval = d
+				} else {
+					healthTimeout = d
+				}
+			case "metadata_txt":
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				metadataTXT = true
+			case "status_acl":
+				cidrs := c.RemainingArgs()
+				if len(cidrs) == 0 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				for _, cidr := range cidrs {
+					_, n, err := net.ParseCIDR(cidr)
+					if err != nil {
+						return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse status_acl network: %q", cidr))
+					}
+					statusACL = append(statusACL, n)
+				}
+			case "wildcard_ptr":
+				if !c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				if err := validWildcardPTR(c.Val()); err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse wildcard_ptr: %v", err))
+				}
+				wildcardPTR = c.Val()
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "fallback_name":
+				if !c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				if err := validFallbackName(c.Val()); err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse fallback_name: %v", err))
+				}
+				fallbackName = c.Val()
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "reverse_zones":
+				zoneArgs := c.RemainingArgs()
+				if len(zoneArgs) == 0 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				zones, err := reverseZonesFromArgs(zoneArgs)
+				if err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse reverse_zones: %v", err))
+				}
+				reverseZoneNames = append(reverseZoneNames, zones...)
+			case "classless_reverse":
+				classlessArgs := c.RemainingArgs()
+				if len(classlessArgs) < 1 || len(classlessArgs) > 2 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				name := ""
+				if len(classlessArgs) == 2 {
+					name = classlessArgs[1]
+				}
+				zone, err := newClasslessZone(classlessArgs[0], name)
+				if err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse classless_reverse: %v", err))
+				}
+				classless = append(classless, zone)
+				reverseZoneNames = append(reverseZoneNames, zone.name)
+			case "discover_reverse_zones":
+				discover = DISCOVER_SUBNETS
+				if c.NextArg() {
+					discover = c.Val()
+					if discover != DISCOVER_SUBNETS && discover != DISCOVER_SUBNETPOOLS {
+						return plugin.Error(PLUGIN_NAME, c.Errf("unknown discover_reverse_zones source %q, expected %q or %q", discover, DISCOVER_SUBNETS, DISCOVER_SUBNETPOOLS))
+					}
+				}
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+			case "port_forwardings":
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				portForwardings = true
+			case "dual_stack":
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				dualStack = true
+			case "loadbalancers":
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				loadBalancers = true
+			case "nova_names":
+				novaArgs := c.RemainingArgs()
+				if len(novaArgs) < 1 || len(novaArgs) > 2 {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				if len(novaArgs) == 2 && novaArgs[1] != "all" {
+					return plugin.Error(PLUGIN_NAME, c.Errf("unknown nova_names option %q, expected \"all\"", novaArgs[1]))
+				}
+				var err error
+				if nova, err = newNovaNames(novaArgs[0], len(novaArgs) == 2); err != nil {
+					return plugin.Error(PLUGIN_NAME, c.Errf("Unable to parse nova_names: %v", err))
+				}
+			case "server_groups":
+				if c.NextArg() {
+					return plugin.Error(PLUGIN_NAME, c.ArgErr())
+				}
+				serverGroups = true
 			default:
 				return plugin.Error(PLUGIN_NAME, c.Errf("unknown property %q", c.Val()))
 			}
 		}
+		if serverGroups {
+			if nova == nil {
+				return plugin.Error(PLUGIN_NAME, c.Errf("server_groups requires nova_names"))
+			}
+			nova.groups = true
+		}
 
 		osc, err := NewOpenStackClient()
 		if err != nil {
@@ -73,6 +378,29 @@ func setup(c *caddy.Controller) error {
 		ctx, cancel := context.WithCancel(context.Background())
 		of := New(osc, refresh, ttl)
 		of.Origins = plugin.OriginsFromArgsOrServerBlock(args, c.ServerBlockKeys)
+		if exportDir != "" {
+			of.exporter = newExporter(exportDir)
+		}
+		of.auditSinks = auditSinks
+		of.conflictPolicy = conflictPolicy
+		of.owners = owners
+		of.guard = guard
+		of.grace = grace
+		of.eligibility = elig
+		of.metadataTXT = metadataTXT
+		of.statusACL = statusACL
+		of.wildcardPTR = wildcardPTR
+		of.fallbackName = fallbackName
+		of.reverseZoneNames = reverseZoneNames
+		of.classless = classless
+		of.discover = discover
+		of.portForwardings = portForwardings
+		of.dualStack = dualStack
+		of.loadBalancers = loadBalancers
+		of.nova = nova
+		if len(healthChecks) > 0 {
+			of.health = newHealthChecker(healthChecks, healthInterval, healthTimeout)
+		}
 
 		if err := of.Run(ctx); err != nil {
 			cancel()
@@ -83,7 +411,24 @@ func setup(c *caddy.Controller) error {
 			return of
 		})
 
+		c.OnStartup(func() error {
+			if h := dnsserver.GetConfig(c).Handler("trace"); h != nil {
+				if t, ok := h.(trace.Trace); ok {
+					of.SetTracer(t.Tracer())
+				}
+			}
+			return nil
+		})
 		c.OnShutdown(func() error { cancel(); return nil })
+		for _, sink := range auditSinks {
+			c.OnShutdown(sink.Close)
+		}
+
+		if debugListen != "" {
+			ds := newDebugServer(debugListen, of)
+			c.OnStartup(ds.Start)
+			c.OnShutdown(ds.Stop)
+		}
 	}
 
 	return nil
@@ -0,0 +1,86 @@
+package ospfip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/coredns/coredns/request"
+	"github.com/miekg/dns"
+)
+
+// the name answering CHAOS TXT queries with the status of the plugin
+const STATUS_NAME = "status.ospfip."
+
+// return if a query asks for the status of the plugin
+func isStatusQuery(state request.Request) bool {
+	return state.QClass() == dns.ClassCHAOS && state.QType() == dns.TypeTXT && state.Name() == STATUS_NAME
+}
+
+// return if a client is allowed to query the status, nobody is when no networks are configured
+func statusAllowed(acl []*net.IPNet, client string) bool {
+	ip := net.ParseIP(client)
+	if ip == nil {
+		return false
+	}
+	for _, n := range acl {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveStatus answers a status query, clients outside of the configured networks are refused
+func (of *OspFip) serveStatus(w dns.ResponseWriter, r *dns.Msg, state request.Request) (int, error) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if !statusAllowed(of.statusACL, state.IP()) {
+		m.SetRcode(r, dns.RcodeRefused)
+		w.WriteMsg(m)
+		return dns.RcodeRefused, nil
+	}
+	m.Authoritative = true
+	hdr := dns.RR_Header{Name: STATUS_NAME, Rrtype: dns.TypeTXT, Class: dns.ClassCHAOS, Ttl: 0}
+	m.Answer = []dns.RR{&dns.TXT{Hdr: hdr, Txt: of.statusTXT()}}
+	w.WriteMsg(m)
+	return dns.RcodeSuccess, nil
+}
+
+// statusTXT renders the status of the last sync and the served records as TXT strings
+func (of *OspFip) statusTXT() []string {
+	of.mutex.RLock()
+	defer of.mutex.RUnlock()
+
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	result := "success"
+	if of.lastSync.IsZero() {
+		result = "pending"
+	} else if of.lastSyncErr != nil {
+		result = "failure: " + of.lastSyncErr.Error()
+	}
+	txt := []string{
+		"last_sync=" + formatTime(of.lastSync),
+		"result=" + result,
+		"last_success=" + formatTime(of.lastSuccess),
+		fmt.Sprintf("records=%d", len(of.records)),
+		fmt.Sprintf("zones=%d", len(of.zoneNames)),
+		"origins=" + strings.Join(of.Origins, ","),
+	}
+	if of.pending != nil {
+		txt = append(txt, fmt.Sprintf("held=%d", len(of.pending.records)))
+	}
+	// a TXT string holds at most 255 characters
+	for i, s := range txt {
+		if len(s) > 255 {
+			txt[i] = s[:255]
+		}
+	}
+	return txt
+}
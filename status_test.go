@@ -0,0 +1,72 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+)
+
+func TestServeStatus(t *testing.T) {
+	_, local, _ := net.ParseCIDR("10.240.0.0/24")
+	cases := []struct {
+		name   string
+		acl    []*net.IPNet
+		rcode  int
+		answer bool
+	}{
+		{name: "client within acl", acl: []*net.IPNet{local}, rcode: dns.RcodeSuccess, answer: true},
+		{name: "client outside acl", acl: []*net.IPNet{{IP: net.IPv4(192, 0, 2, 0), Mask: net.CIDRMask(24, 32)}}, rcode: dns.RcodeRefused},
+		{name: "no acl refuses everyone", rcode: dns.RcodeRefused},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			of := OspFip{
+				Origins:     []string{"example.net."},
+				zoneNames:   []string{"example.net."},
+				records:     []record{{Name: "api.example.net."}, {Name: "www.example.net."}},
+				lastSync:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				lastSuccess: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				statusACL:   tt.acl,
+				Next:        test.NextHandler(dns.RcodeNameError, nil),
+			}
+			// the test writer reports 10.240.0.1 as client
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			r := new(dns.Msg)
+			r.SetQuestion(STATUS_NAME, dns.TypeTXT)
+			r.Question[0].Qclass = dns.ClassCHAOS
+
+			rc, err := of.ServeDNS(context.TODO(), w, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rc != tt.rcode || w.Msg.Rcode != tt.rcode {
+				t.Fatalf("expected rcode %d, got %d", tt.rcode, rc)
+			}
+			if !tt.answer {
+				if len(w.Msg.Answer) != 0 {
+					t.Fatalf("expected no answer, got %v", w.Msg.Answer)
+				}
+				return
+			}
+			txt := strings.Join(w.Msg.Answer[0].(*dns.TXT).Txt, " ")
+			expected := "last_sync=2024-01-02T03:04:05Z result=success last_success=2024-01-02T03:04:05Z records=2 zones=1 origins=example.net."
+			if txt != expected {
+				t.Fatalf("expected %q, got %q", expected, txt)
+			}
+		})
+	}
+}
+
+func TestStatusTXTFailure(t *testing.T) {
+	of := OspFip{lastSync: time.Now(), lastSyncErr: fmt.Errorf("failed to list floating ips")}
+	if txt := of.statusTXT(); txt[1] != "result=failure: failed to list floating ips" || txt[2] != "last_success=never" {
+		t.Fatalf("expected failed sync in status, got %v", txt)
+	}
+}
@@ -0,0 +1,56 @@
+package ospfip
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// placeholder in name templates which is replaced by the dashed address of a floating ip
+const IP_PLACEHOLDER = "{ip}"
+
+// an address used to validate templates
+var templateSampleIP = net.ParseIP("192.0.2.1")
+
+// return the address as a label, e.g. 10-0-0-1 or 2001-db8-0-0-0-0-0-1
+func dashedIP(ip net.IP) string {
+	if v4 := ip.To4(); v4 != nil {
+		return strings.ReplaceAll(v4.String(), ".", "-")
+	}
+	groups := make([]string, 0, 8)
+	for i := 0; i < net.IPv6len; i += 2 {
+		groups = append(groups, fmt.Sprintf("%x", uint16(ip[i])<<8|uint16(ip[i+1])))
+	}
+	return strings.Join(groups, "-")
+}
+
+// expand the address placeholder of a template
+func expandTemplate(template string, ip net.IP) string {
+	return strings.ReplaceAll(template, IP_PLACEHOLDER, dashedIP(ip))
+}
+
+// validate the label used in place of the '*' of wildcard names as PTR target
+func validWildcardPTR(template string) error {
+	if errs := validation.IsDNS1123Label(expandTemplate(template, templateSampleIP)); len(errs) > 0 {
+		return fmt.Errorf("%q doesn't result in a valid label: %s", template, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// validate the name template for floating ips without a hostname tag
+func validFallbackName(template string) error {
+	if !strings.Contains(template, IP_PLACEHOLDER) {
+		return fmt.Errorf("%q doesn't contain %s", template, IP_PLACEHOLDER)
+	}
+	if errs := validation.IsDNS1123Subdomain(unFqdn(expandTemplate(template, templateSampleIP))); len(errs) > 0 {
+		return fmt.Errorf("%q doesn't result in a valid name: %s", template, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// return the PTR target of a wildcard name, the '*' label is replaced by the expanded template
+func wildcardPTRTarget(name, template string, ip net.IP) string {
+	return expandTemplate(template, ip) + strings.TrimPrefix(name, "*")
+}
@@ -0,0 +1,93 @@
+package ospfip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/extensions/layer3/floatingips"
+)
+
+func TestDashedIP(t *testing.T) {
+	cases := []struct {
+		ip       string
+		expected string
+	}{
+		{ip: "10.0.0.1", expected: "10-0-0-1"},
+		{ip: "2001:db8::1", expected: "2001-db8-0-0-0-0-0-1"},
+		{ip: "::ffff:192.0.2.1", expected: "192-0-2-1"},
+	}
+	for _, tt := range cases {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := dashedIP(net.ParseIP(tt.ip)); got != tt.expected {
+				t.Fatalf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNameTemplates(t *testing.T) {
+	cases := []struct {
+		name     string
+		validate func(string) error
+		template string
+		valid    bool
+	}{
+		{name: "wildcard label", validate: validWildcardPTR, template: "ingress", valid: true},
+		{name: "wildcard template", validate: validWildcardPTR, template: "ingress-{ip}", valid: true},
+		{name: "wildcard with dots", validate: validWildcardPTR, template: "a.b", valid: false},
+		{name: "fallback template", validate: validFallbackName, template: "ip-{ip}.fip.example.net", valid: true},
+		{name: "fallback without placeholder", validate: validFallbackName, template: "fip.example.net", valid: false},
+		{name: "fallback with invalid name", validate: validFallbackName, template: "ip_{ip}.fip.example.net", valid: false},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(tt.template)
+			if tt.valid && err != nil {
+				t.Fatalf("expected %q to be valid, got %s", tt.template, err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatalf("expected %q to be invalid", tt.template)
+			}
+		})
+	}
+}
+
+func TestSynthesizedNames(t *testing.T) {
+	of := New(nil, 0, 60)
+	of.Origins = []string{"example.net."}
+	of.wildcardPTR = "ingress-{ip}"
+	of.fallbackName = "ip-{ip}.fip.example.net"
+
+	wildcard := floatingips.FloatingIP{ID: "fip-1", FloatingIP: "10.0.0.1", Tags: []string{PLUGIN_TAG_IDENTIFIER, PLUGIN_TAG_IDENTIFIER + ":*.apps.example.net"}}
+	untagged := floatingips.FloatingIP{ID: "fip-2", FloatingIP: "10.0.0.2", Tags: []string{PLUGIN_TAG_IDENTIFIER}}
+	invalid := floatingips.FloatingIP{ID: "fip-3", FloatingIP: "10.0.0.3", Tags: []string{PLUGIN_TAG_IDENTIFIER, PLUGIN_TAG_IDENTIFIER + ":not_valid"}}
+
+	records := make([]record, 0)
+	for _, fip := range []floatingips.FloatingIP{wildcard, untagged} {
+		r, _, err := of.recordFromFip(fip)
+		if err != nil || r == nil {
+			t.Fatalf("expected a record for %s, got %v", fip.ID, err)
+		}
+		records = append(records, *r)
+	}
+	if _, _, err := of.recordFromFip(invalid); err == nil {
+		t.Fatalf("expected no fallback for a floating ip with an invalid hostname tag")
+	}
+	if records[1].Name != "ip-10-0-0-2.fip.example.net." {
+		t.Fatalf("expected fallback name, got %s", records[1].Name)
+	}
+
+	zones, _, reverse, _, errs := buildZones(records, 60)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %+v", errs)
+	}
+	if reverse["10.0.0.1"] != "ingress-10-0-0-1.apps.example.net." {
+		t.Fatalf("expected synthesized PTR target for the wildcard, got %q", reverse["10.0.0.1"])
+	}
+	if reverse["10.0.0.2"] != "ip-10-0-0-2.fip.example.net." {
+		t.Fatalf("expected PTR to the fallback name, got %q", reverse["10.0.0.2"])
+	}
+	if _, ok := zones["fip.example.net."].Search("ip-10-0-0-2.fip.example.net."); !ok {
+		t.Fatalf("expected forward record for the fallback name")
+	}
+}
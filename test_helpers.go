@@ -41,6 +41,19 @@ const untaggedFip = `
         "tags": []
 }`
 
+const malformedFip = `
+{
+        "id": "0b7c1c4e-5d9a-4b6f-8e3a-2f1d6c9e7b55",
+        "tenant_id": "eac7ae24f17790eec436bd46c71834d8",
+        "floating_ip_address": "192.0.0.300",
+        "fixed_ip_address": "192.168.0.7",
+        "status": "DOWN",
+        "tags": [
+          "coredns:plugin:ospfip",
+          "coredns:plugin:ospfip:broken.mycluster.example.net"
+        ]
+}`
+
 var ListResponse = func(fipResp ...string) string {
 	return fmt.Sprintf(`
         {
@@ -0,0 +1,89 @@
+package ospfip
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	ot "github.com/opentracing/opentracing-go"
+	otext "github.com/opentracing/opentracing-go/ext"
+)
+
+const (
+	SPAN_SYNC                  = "ospfip.sync"
+	SPAN_ZONE_MATCH            = "ospfip.zone_match"
+	SPAN_LOOKUP                = "ospfip.lookup"
+	SPAN_PTR                   = "ospfip.ptr"
+	SPAN_LIST_FIPS             = "neutron.list_floatingips"
+	SPAN_LIST_PORT_FORWARDINGS = "neutron.list_port_forwardings"
+	SPAN_LIST_SUBNETS          = "neutron.list_subnets"
+	SPAN_LIST_PORTS            = "neutron.list_ports"
+	SPAN_LIST_SERVERS          = "nova.list_servers"
+	SPAN_LIST_SERVER_GROUPS    = "nova.list_server_groups"
+	SPAN_LIST_LOADBALANCERS    = "octavia.list_loadbalancers"
+	SPAN_GET_SUBNETPOOL        = "neutron.get_subnetpool"
+	SPAN_KEYSTONE              = "keystone.auth"
+	SPAN_HTTP_REQUEST          = "openstack.request"
+)
+
+// childSpan starts a span as child of the span carried by ctx.
+// When ctx carries no span (tracing disabled) a no-op span is returned so callers don't have to check.
+func childSpan(ctx context.Context, name string) (ot.Span, context.Context) {
+	parent := ot.SpanFromContext(ctx)
+	if parent == nil {
+		return ot.NoopTracer{}.StartSpan(name), ctx
+	}
+	span := parent.Tracer().StartSpan(name, ot.ChildOf(parent.Context()))
+	return span, ot.ContextWithSpan(ctx, span)
+}
+
+// rootSpan starts a new trace using the tracer of the trace plugin, if any.
+// Used for the background sync which isn't triggered by a query.
+func rootSpan(ctx context.Context, tracer ot.Tracer, name string) (ot.Span, context.Context) {
+	if tracer == nil {
+		return ot.NoopTracer{}.StartSpan(name), ctx
+	}
+	span := tracer.StartSpan(name)
+	return span, ot.ContextWithSpan(ctx, span)
+}
+
+// tracingTransport wraps every request to the OpenStack API in a span when the request context carries one.
+// Since gophercloud issues one request per page, this results in a span per page fetch.
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	parent := ot.SpanFromContext(req.Context())
+	if parent == nil {
+		return base.RoundTrip(req)
+	}
+
+	span := parent.Tracer().StartSpan(requestSpanName(req), ot.ChildOf(parent.Context()))
+	defer span.Finish()
+	otext.HTTPMethod.Set(span, req.Method)
+	otext.HTTPUrl.Set(span, req.URL.String())
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		otext.LogError(span, err)
+		return resp, err
+	}
+	otext.HTTPStatusCode.Set(span, uint16(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		otext.Error.Set(span, true)
+	}
+	return resp, nil
+}
+
+// return the span name for a request to the OpenStack API
+func requestSpanName(req *http.Request) string {
+	if strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/auth/tokens") {
+		return SPAN_KEYSTONE
+	}
+	return SPAN_HTTP_REQUEST
+}
@@ -0,0 +1,82 @@
+package ospfip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	fake "github.com/gophercloud/gophercloud/v2/openstack/networking/v2/common"
+	th "github.com/gophercloud/gophercloud/v2/testhelper"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestRequestSpanName(t *testing.T) {
+	cases := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{name: "keystone token request", url: "http://keystone:5000/v3/auth/tokens", expected: SPAN_KEYSTONE},
+		{name: "keystone token request with trailing slash", url: "http://keystone:5000/v3/auth/tokens/", expected: SPAN_KEYSTONE},
+		{name: "neutron list request", url: "http://neutron:9696/v2.0/floatingips?tags=coredns", expected: SPAN_HTTP_REQUEST},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if got := requestSpanName(req); got != tt.expected {
+				t.Fatalf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUpdateRecordsSpans(t *testing.T) {
+	th.SetupHTTP()
+	defer th.TeardownHTTP()
+
+	th.Mux.HandleFunc("/v2.0/floatingips", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ListResponse(taggedFip, untaggedFip))
+	})
+
+	client := fake.ServiceClient()
+	client.HTTPClient.Transport = &tracingTransport{base: client.HTTPClient.Transport}
+	of := New(&OpenStackClient{client: client}, 5*time.Minute, 5)
+	of.Origins = []string{"."}
+	tracer := mocktracer.New()
+	of.SetTracer(tracer)
+
+	if err := of.updateRecords(context.TODO()); err != nil {
+		t.Fatalf("failed to update records: %s", err)
+	}
+
+	spans := map[string]*mocktracer.MockSpan{}
+	for _, span := range tracer.FinishedSpans() {
+		spans[span.OperationName] = span
+	}
+	for _, name := range []string{SPAN_SYNC, SPAN_LIST_FIPS, SPAN_HTTP_REQUEST} {
+		if _, ok := spans[name]; !ok {
+			t.Fatalf("expected span %s, got %+v", name, tracer.FinishedSpans())
+		}
+	}
+	if spans[SPAN_LIST_FIPS].ParentID != spans[SPAN_SYNC].SpanContext.SpanID {
+		t.Fatalf("expected %s to be a child of %s", SPAN_LIST_FIPS, SPAN_SYNC)
+	}
+	if spans[SPAN_HTTP_REQUEST].ParentID != spans[SPAN_LIST_FIPS].SpanContext.SpanID {
+		t.Fatalf("expected %s to be a child of %s", SPAN_HTTP_REQUEST, SPAN_LIST_FIPS)
+	}
+	sync := spans[SPAN_SYNC]
+	if got := sync.Tag("ospfip.fips"); got != 2 {
+		t.Fatalf("expected 2 fips, got %v", got)
+	}
+	if got := sync.Tag("ospfip.zones"); got != 1 {
+		t.Fatalf("expected 1 zone, got %v", got)
+	}
+	if got := sync.Tag("ospfip.validation_failures"); got != 1 {
+		t.Fatalf("expected 1 validation failure, got %v", got)
+	}
+}
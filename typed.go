@@ -0,0 +1,112 @@
+package ospfip
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coredns/coredns/plugin"
+	"github.com/miekg/dns"
+)
+
+// tags like 'coredns:plugin:ospfip:rr:SRV:_https._tcp:0 0 443 @' declare additional records,
+// names in the owner and data are relative to the hostname of the floating ip and '@' is the hostname itself
+const TYPED_TAG_PREFIX = PLUGIN_TAG_IDENTIFIER + ":rr:"
+
+// the record types which can be declared through tags
+var typedRecordTypes = map[string]uint16{
+	"CNAME": dns.TypeCNAME,
+	"TXT":   dns.TypeTXT,
+	"SRV":   dns.TypeSRV,
+	"MX":    dns.TypeMX,
+	"CAA":   dns.TypeCAA,
+}
+
+// typedRecord is an additional record declared through a tag of a floating ip
+type typedRecord struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Data string `json:"data"`
+	Tag  string `json:"tag"`
+}
+
+// return if a tag declares a typed record
+func isTypedTag(tag string) bool {
+	return strings.HasPrefix(tag, TYPED_TAG_PREFIX)
+}
+
+// extract the typed records anchored on a hostname from a list of tags, invalid ones are returned as rejected
+func typedRecordsFromTags(tags []string, hostname string, origins []string) ([]typedRecord, []rejection) {
+	typed := make([]typedRecord, 0)
+	rejected := make([]rejection, 0)
+	for _, tag := range tags {
+		if !isTypedTag(tag) {
+			continue
+		}
+		t, err := parseTypedTag(tag, hostname, origins)
+		if err != nil {
+			rejected = append(rejected, rejection{Tag: tag, Reason: err.Error()})
+			continue
+		}
+		typed = append(typed, t)
+	}
+	return typed, rejected
+}
+
+func parseTypedTag(tag, hostname string, origins []string) (typedRecord, error) {
+	parts := strings.SplitN(strings.TrimPrefix(tag, TYPED_TAG_PREFIX), ":", 3)
+	if len(parts) != 3 {
+		return typedRecord{}, fmt.Errorf("expected '%s<type>:<owner>:<data>'", TYPED_TAG_PREFIX)
+	}
+	rrType, owner, data := strings.ToUpper(parts[0]), parts[1], parts[2]
+	if _, ok := typedRecordTypes[rrType]; !ok {
+		return typedRecord{}, fmt.Errorf("unsupported record type %q", parts[0])
+	}
+
+	// parse as a zone file line with the hostname as origin to resolve relative names
+	zp := dns.NewZoneParser(strings.NewReader(fmt.Sprintf("%s 0 IN %s %s", owner, rrType, data)), hostname, "")
+	rr, ok := zp.Next()
+	if err := zp.Err(); err != nil {
+		return typedRecord{}, fmt.Errorf("failed to parse record: %v", err)
+	}
+	if !ok {
+		return typedRecord{}, fmt.Errorf("failed to parse record")
+	}
+
+	name := rr.Header().Name
+	if !dns.IsSubDomain(zoneFromRecord(hostname), name) || plugin.Zones(origins).Matches(name) == "" {
+		return typedRecord{}, fmt.Errorf("'%s' is outside the zone of '%s'", name, hostname)
+	}
+	if rrType == "CNAME" && name == hostname {
+		return typedRecord{}, fmt.Errorf("a CNAME can't share the name of the floating ip")
+	}
+	return typedRecord{
+		Name: name,
+		Type: rrType,
+		Data: strings.TrimPrefix(rr.String(), rr.Header().String()),
+		Tag:  tag,
+	}, nil
+}
+
+// drop the CNAME records whose name is used by any other record, a CNAME can't coexist with other data
+func withoutCNAMEClashes(records []record) ([]record, []rejection) {
+	names := make(map[string]int)
+	for _, r := range records {
+		names[r.Name]++
+		for _, t := range r.Typed {
+			names[t.Name]++
+		}
+	}
+	rejected := make([]rejection, 0)
+	for i, r := range records {
+		kept := make([]typedRecord, 0, len(r.Typed))
+		for _, t := range r.Typed {
+			if t.Type == "CNAME" && names[t.Name] > 1 {
+				rejected = append(rejected, rejection{FipID: r.FipID, Tag: t.Tag, Reason: fmt.Sprintf("CNAME '%s' clashes with other records", t.Name)})
+				continue
+			}
+			kept = append(kept, t)
+		}
+		records[i].Typed = kept
+	}
+	return records, rejected
+}
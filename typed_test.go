@@ -0,0 +1,128 @@
+package ospfip
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coredns/coredns/plugin/pkg/dnstest"
+	"github.com/coredns/coredns/plugin/test"
+	"github.com/miekg/dns"
+)
+
+func TestTypedRecordsFromTags(t *testing.T) {
+	origins := []string{"example.net."}
+	cases := []struct {
+		name     string
+		tag      string
+		expected *typedRecord
+	}{
+		{name: "srv relative to hostname", tag: "coredns:plugin:ospfip:rr:SRV:_https._tcp:0 10 443 @", expected: &typedRecord{Name: "_https._tcp.api.example.net.", Type: "SRV", Data: "0 10 443 api.example.net."}},
+		{name: "cname alias", tag: "coredns:plugin:ospfip:rr:CNAME:www.example.net.:@", expected: &typedRecord{Name: "www.example.net.", Type: "CNAME", Data: "api.example.net."}},
+		{name: "caa at hostname", tag: `coredns:plugin:ospfip:rr:caa:@:0 issue "letsencrypt.org"`, expected: &typedRecord{Name: "api.example.net.", Type: "CAA", Data: `0 issue "letsencrypt.org"`}},
+		{name: "txt containing attribute-like data", tag: `coredns:plugin:ospfip:rr:TXT:@:"owner=team-a"`, expected: &typedRecord{Name: "api.example.net.", Type: "TXT", Data: `"owner=team-a"`}},
+		{name: "mx", tag: "coredns:plugin:ospfip:rr:MX:@:10 mail", expected: &typedRecord{Name: "api.example.net.", Type: "MX", Data: "10 mail.api.example.net."}},
+		{name: "unsupported type", tag: "coredns:plugin:ospfip:rr:NS:@:ns1"},
+		{name: "missing data", tag: "coredns:plugin:ospfip:rr:TXT:@"},
+		{name: "invalid data", tag: "coredns:plugin:ospfip:rr:SRV:@:not a port"},
+		{name: "outside the zone", tag: "coredns:plugin:ospfip:rr:CNAME:www.example.org.:@"},
+		{name: "cname at hostname", tag: "coredns:plugin:ospfip:rr:CNAME:@:other.example.net."},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			typed, rejected := typedRecordsFromTags([]string{"coredns:plugin:ospfip:api.example.net", tt.tag}, "api.example.net.", origins)
+			if tt.expected == nil {
+				if len(typed) != 0 || len(rejected) != 1 {
+					t.Fatalf("expected tag to be rejected, got %+v %+v", typed, rejected)
+				}
+				return
+			}
+			tt.expected.Tag = tt.tag
+			if len(typed) != 1 || typed[0] != *tt.expected {
+				t.Fatalf("expected %+v, got %+v (rejected %+v)", *tt.expected, typed, rejected)
+			}
+		})
+	}
+}
+
+func TestWithoutCNAMEClashes(t *testing.T) {
+	records := []record{
+		{Name: "api.example.net.", FipID: "fip-1", Typed: []typedRecord{{Name: "www.example.net.", Type: "CNAME", Data: "api.example.net."}}},
+		{Name: "www.example.net.", FipID: "fip-2"},
+		{Name: "db.example.net.", FipID: "fip-3", Typed: []typedRecord{{Name: "sql.example.net.", Type: "CNAME", Data: "db.example.net."}}},
+	}
+	records, rejected := withoutCNAMEClashes(records)
+	if len(records[0].Typed) != 0 || len(records[2].Typed) != 1 {
+		t.Fatalf("expected only the clashing CNAME to be dropped, got %+v", records)
+	}
+	if len(rejected) != 1 || rejected[0].FipID != "fip-1" {
+		t.Fatalf("expected the clashing CNAME to be rejected, got %+v", rejected)
+	}
+}
+
+func TestServeDNSTypedRecords(t *testing.T) {
+	records := []record{
+		{Name: "api.example.net.", Zone: "example.net.", Type: "A", IP: "192.0.2.1", FipID: "fip-1", Typed: []typedRecord{
+			{Name: "_https._tcp.api.example.net.", Type: "SRV", Data: "0 10 443 api.example.net."},
+		}},
+		{Name: "app.example.org.", Zone: "example.org.", Type: "A", IP: "192.0.2.2", FipID: "fip-2", Typed: []typedRecord{
+			{Name: "www.example.org.", Type: "CNAME", Data: "api.example.net."},
+			{Name: "ext.example.org.", Type: "CNAME", Data: "www.example.com."},
+		}},
+	}
+	zones, zoneNames, _, _, errs := buildZones(records, 60)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors building zones: %+v", errs)
+	}
+
+	cases := []struct {
+		name     string
+		qname    string
+		qtype    uint16
+		expected []string
+	}{
+		{name: "srv", qname: "_https._tcp.api.example.net.", qtype: dns.TypeSRV, expected: []string{"SRV"}},
+		{name: "cname chased into other zone", qname: "www.example.org.", qtype: dns.TypeA, expected: []string{"CNAME", "A"}},
+		{name: "cname query isn't chased", qname: "www.example.org.", qtype: dns.TypeCNAME, expected: []string{"CNAME"}},
+		{name: "external cname target left to the resolver", qname: "ext.example.org.", qtype: dns.TypeA, expected: []string{"CNAME"}},
+	}
+	of := OspFip{zones: zones, zoneNames: zoneNames, Next: test.NextHandler(dns.RcodeNameError, nil)}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			w := dnstest.NewRecorder(&test.ResponseWriter{})
+			r := new(dns.Msg)
+			r.SetQuestion(tt.qname, tt.qtype)
+			if _, err := of.ServeDNS(context.TODO(), w, r); err != nil {
+				t.Fatal(err)
+			}
+			if w.Msg == nil {
+				t.Fatalf("expected an answer")
+			}
+			got := make([]string, 0)
+			for _, rr := range w.Msg.Answer {
+				got = append(got, dns.TypeToString[rr.Header().Rrtype])
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("expected %v, got %v", tt.expected, w.Msg.Answer)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Fatalf("expected %v, got %v", tt.expected, w.Msg.Answer)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildZonesTypedRecordError(t *testing.T) {
+	records := []record{{
+		Name: "api.example.net.", Zone: "example.net.", Type: "A", IP: "192.0.2.1", FipID: "fip-api",
+		Typed: []typedRecord{{Name: "_https._tcp.api.example.net.", Type: "SRV", Data: "bogus", Tag: "coredns:plugin:ospfip:rr:SRV:_https._tcp:bogus"}},
+	}}
+	_, _, _, _, errs := buildZones(records, 60)
+	if len(errs) != 1 || errs[0].FipID != "fip-api" || errs[0].Tag != "coredns:plugin:ospfip:rr:SRV:_https._tcp:bogus" {
+		t.Fatalf("expected the typed record to be reported, got %+v", errs)
+	}
+	if kept := withoutFailed(records, errs); len(kept) != 1 {
+		t.Fatalf("expected the floating ip to be kept, got %+v", kept)
+	}
+}